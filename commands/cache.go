@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+// newCacheCmd returns `grove hooks cache`, which manages the on_stop result
+// cache used by hooks with cache = true.
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the on_stop hook result cache",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached on_stop hook results",
+		Long: `Remove all cached on_stop hook results.
+
+Hooks with cache = true replay their last outcome while the repository
+state they are keyed on is unchanged. Clearing the cache makes the next
+stop run them again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := corehooks.ClearHookCache()
+			if err != nil {
+				return fmt.Errorf("clear hook cache: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cached result(s) from %s\n", n, corehooks.HookCacheDir())
+			return nil
+		},
+	})
+	return cmd
+}
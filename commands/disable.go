@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/grovetools/core/config"
 	"github.com/spf13/cobra"
@@ -42,135 +43,302 @@ func resolveRepoRoot(startDir string) (string, error) {
 	}
 }
 
-// loadOnStopHooks resolves the repo root and returns its [[hooks.on_stop]]
-// entries plus the resolved root path.
-func loadOnStopHooks(repoFlag string) (string, []config.HookCommand, error) {
+// loadOnStopHooks resolves the repo root and returns it with its
+// [[hooks.on_stop]] entries and the names of its [[hooks.stop_gate]] entries,
+// which take disable markers too.
+func loadOnStopHooks(repoFlag string) (string, []config.HookCommand, []string, error) {
 	start := repoFlag
 	if start == "" {
 		wd, err := os.Getwd()
 		if err != nil {
-			return "", nil, fmt.Errorf("get cwd: %w", err)
+			return "", nil, nil, fmt.Errorf("get cwd: %w", err)
 		}
 		start = wd
 	}
 	root, err := resolveRepoRoot(start)
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 	cfg, err := config.LoadFrom(root)
 	if err != nil {
-		return root, nil, fmt.Errorf("load grove config: %w", err)
+		return root, nil, nil, fmt.Errorf("load grove config: %w", err)
 	}
 	var hooksCfg config.HooksConfig
 	if err := cfg.UnmarshalExtension("hooks", &hooksCfg); err != nil {
-		return root, nil, fmt.Errorf("unmarshal hooks config: %w", err)
+		return root, nil, nil, fmt.Errorf("unmarshal hooks config: %w", err)
 	}
-	return root, hooksCfg.OnStop, nil
+	var gatesCfg struct {
+		StopGate []corehooks.StopGate `yaml:"stop_gate"`
+	}
+	if err := cfg.UnmarshalExtension("hooks", &gatesCfg); err != nil {
+		return root, nil, nil, fmt.Errorf("unmarshal hooks config: %w", err)
+	}
+	gates := make([]string, 0, len(gatesCfg.StopGate))
+	for _, g := range gatesCfg.StopGate {
+		if g.Name != "" {
+			gates = append(gates, g.Name)
+		}
+	}
+	return root, hooksCfg.OnStop, gates, nil
 }
 
-func newHookNameError(hookName string, available []config.HookCommand) error {
-	names := make([]string, 0, len(available))
-	for _, h := range available {
+// checkHookName reports an error unless hookName names an on_stop hook or a
+// stop gate.
+func checkHookName(hookName string, onStop []config.HookCommand, gates []string) error {
+	names := make([]string, 0, len(onStop)+len(gates))
+	for _, h := range onStop {
 		names = append(names, h.Name)
 	}
+	names = append(names, gates...)
+	for _, name := range names {
+		if name == hookName {
+			return nil
+		}
+	}
 	sort.Strings(names)
 	if len(names) == 0 {
-		return fmt.Errorf("hook %q not found: no [[hooks.on_stop]] entries in grove.toml", hookName)
+		return fmt.Errorf("hook %q not found: no [[hooks.on_stop]] or [[hooks.stop_gate]] entries in grove.toml", hookName)
 	}
-	return fmt.Errorf("hook %q not found in [[hooks.on_stop]]; available: %s",
+	return fmt.Errorf("hook %q not found in [[hooks.on_stop]] or [[hooks.stop_gate]]; available: %s",
 		hookName, strings.Join(names, ", "))
 }
 
+// hookScopeFlags are the --scope/--session flags shared by disable and
+// enable.
+type hookScopeFlags struct {
+	scope     string
+	sessionID string
+}
+
+func (f *hookScopeFlags) register(cmd *cobra.Command, scopeUsage string) {
+	cmd.Flags().StringVar(&f.scope, "scope", "", scopeUsage)
+	cmd.Flags().StringVar(&f.sessionID, "session", "", "Session id for --scope session (implies it)")
+}
+
+// resolve returns the chosen scope, or "" when none was given. A --session
+// must name a known session.
+func (f *hookScopeFlags) resolve() (corehooks.DisableScope, error) {
+	if f.sessionID != "" {
+		if _, err := corehooks.ResolveSessionID(f.sessionID); err != nil {
+			return "", err
+		}
+	}
+	if f.scope == "" {
+		if f.sessionID != "" {
+			return corehooks.DisableSession, nil
+		}
+		return "", nil
+	}
+	scope, err := corehooks.ParseDisableScope(f.scope)
+	if err != nil {
+		return "", err
+	}
+	if scope == corehooks.DisableSession && f.sessionID == "" {
+		return "", fmt.Errorf("--scope session needs --session <id>")
+	}
+	return scope, nil
+}
+
+// describeScope names where a marker applies, for command output.
+func describeScope(scope corehooks.DisableScope, root, sessionID string) string {
+	switch scope {
+	case corehooks.DisableWorktree:
+		return "worktree " + root
+	case corehooks.DisableSession:
+		return "session " + sessionID
+	default:
+		return filepath.Base(root)
+	}
+}
+
+// parseDisableUntil parses an --until time: RFC 3339, "2006-01-02 15:04",
+// "2006-01-02", or "15:04" (the next such time of day), in local time.
+func parseDisableUntil(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if clock, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --until %q: want RFC 3339, \"2006-01-02 15:04\", \"2006-01-02\" or \"15:04\"", s)
+}
+
+// formatRemaining renders the time left on a temporary disable.
+func formatRemaining(expiresAt, now time.Time) string {
+	left := expiresAt.Sub(now)
+	var remaining string
+	switch {
+	case left >= time.Hour:
+		left = left.Round(time.Minute)
+		remaining = fmt.Sprintf("%dh%02dm", int(left.Hours()), int(left.Minutes())%60)
+	case left >= time.Minute:
+		remaining = fmt.Sprintf("%dm", int(left.Round(time.Minute).Minutes()))
+	default:
+		remaining = fmt.Sprintf("%ds", int(left.Round(time.Second).Seconds()))
+	}
+	return fmt.Sprintf("%s left (until %s)", remaining, expiresAt.Local().Format("2006-01-02 15:04"))
+}
+
 func newDisableHookCmd() *cobra.Command {
-	var repoFlag, reason string
+	var (
+		repoFlag, reason string
+		scopeFlags       hookScopeFlags
+		forFlag          time.Duration
+		untilFlag        string
+	)
 	cmd := &cobra.Command{
 		Use:   "disable <hook-name>",
-		Short: "Disable an on_stop hook for the current repo (creates a marker file)",
-		Args:  cobra.ExactArgs(1),
+		Short: "Disable an on_stop hook or stop gate for the current repo (creates a marker file)",
+		Long: `Disable an on_stop hook or a stop gate by creating a marker file.
+
+--scope repo (the default) disables it in every worktree of the repo,
+--scope worktree only in this checkout, and --scope session (or --session
+<id>) only for one session, until the session ends.
+
+--for 2h or --until <time> makes the disable temporary: the hook runs again
+once the time has passed, without an explicit enable.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			hookName := args[0]
-			root, onStop, err := loadOnStopHooks(repoFlag)
+			scope, err := scopeFlags.resolve()
 			if err != nil {
 				return err
 			}
-			found := false
-			for _, h := range onStop {
-				if h.Name == hookName {
-					found = true
-					break
+			if scope == "" {
+				scope = corehooks.DisableRepo
+			}
+			var expiresAt time.Time
+			switch now := time.Now(); {
+			case forFlag != 0 && untilFlag != "":
+				return fmt.Errorf("--for and --until are mutually exclusive")
+			case forFlag < 0:
+				return fmt.Errorf("--for must be positive")
+			case forFlag > 0:
+				expiresAt = now.Add(forFlag)
+			case untilFlag != "":
+				if expiresAt, err = parseDisableUntil(untilFlag, now); err != nil {
+					return err
+				}
+				if !expiresAt.After(now) {
+					return fmt.Errorf("--until %s is in the past", untilFlag)
 				}
 			}
-			if !found {
-				return newHookNameError(hookName, onStop)
+			root, onStop, gates, err := loadOnStopHooks(repoFlag)
+			if err != nil {
+				return err
 			}
-			if err := corehooks.DisableHook(root, hookName, reason); err != nil {
+			if err := checkHookName(hookName, onStop, gates); err != nil {
+				return err
+			}
+			if err := corehooks.DisableHookInScope(root, scopeFlags.sessionID, hookName, reason, scope, expiresAt); err != nil {
 				return fmt.Errorf("write marker: %w", err)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Disabled hook %q for %s\n", hookName, filepath.Base(root))
+			msg := fmt.Sprintf("Disabled hook %q for %s", hookName, describeScope(scope, root, scopeFlags.sessionID))
+			if !expiresAt.IsZero() {
+				msg += "; " + formatRemaining(expiresAt, time.Now())
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), msg)
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&repoFlag, "repo", "", "Repo directory (defaults to cwd)")
 	cmd.Flags().StringVar(&reason, "reason", "", "Optional reason recorded in the marker file")
+	cmd.Flags().DurationVar(&forFlag, "for", 0, "Disable for this long (e.g. 30m, 2h)")
+	cmd.Flags().StringVar(&untilFlag, "until", "", "Disable until this time (e.g. 18:00, \"2026-01-02 09:00\")")
+	scopeFlags.register(cmd, "Where the disable applies: repo (default), worktree or session")
 	return cmd
 }
 
 func newEnableHookCmd() *cobra.Command {
-	var repoFlag string
+	var (
+		repoFlag   string
+		scopeFlags hookScopeFlags
+	)
 	cmd := &cobra.Command{
 		Use:   "enable <hook-name>",
-		Short: "Re-enable an on_stop hook for the current repo (removes the marker file)",
-		Args:  cobra.ExactArgs(1),
+		Short: "Re-enable an on_stop hook or stop gate for the current repo (removes the marker file)",
+		Long: `Re-enable an on_stop hook or a stop gate by removing its marker files.
+
+Without --scope the repo and worktree markers are removed, plus the session
+marker when --session is given.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			hookName := args[0]
-			root, onStop, err := loadOnStopHooks(repoFlag)
+			scope, err := scopeFlags.resolve()
 			if err != nil {
 				return err
 			}
-			found := false
-			for _, h := range onStop {
-				if h.Name == hookName {
-					found = true
-					break
-				}
+			root, onStop, gates, err := loadOnStopHooks(repoFlag)
+			if err != nil {
+				return err
+			}
+			if err := checkHookName(hookName, onStop, gates); err != nil {
+				return err
 			}
-			if !found {
-				return newHookNameError(hookName, onStop)
+			if scopeFlags.scope == "" {
+				// Without a session id this leaves session markers alone.
+				err = corehooks.EnableHook(root, hookName)
+				if err == nil {
+					err = corehooks.EnableHookInScope(root, scopeFlags.sessionID, hookName, corehooks.DisableSession)
+				}
+				scope = corehooks.DisableRepo
+			} else {
+				err = corehooks.EnableHookInScope(root, scopeFlags.sessionID, hookName, scope)
 			}
-			if err := corehooks.EnableHook(root, hookName); err != nil {
+			if err != nil {
 				return fmt.Errorf("remove marker: %w", err)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Enabled hook %q for %s\n", hookName, filepath.Base(root))
+			fmt.Fprintf(cmd.OutOrStdout(), "Enabled hook %q for %s\n", hookName, describeScope(scope, root, scopeFlags.sessionID))
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&repoFlag, "repo", "", "Repo directory (defaults to cwd)")
+	scopeFlags.register(cmd, "Only remove the marker in this scope: repo, worktree or session")
 	return cmd
 }
 
 // hookListEntry is the JSON shape emitted by `grove hooks list --json`.
 type hookListEntry struct {
-	Name             string `json:"name"`
-	Command          string `json:"command"`
-	Disabled         bool   `json:"disabled"`
-	DisableReason    string `json:"disable_reason,omitempty"`
-	MarkerPath       string `json:"marker_path,omitempty"`
-	DisableEnv       string `json:"disable_env,omitempty"`
-	DisableEnvActive bool   `json:"disable_env_active,omitempty"`
-	EnableEnv        string `json:"enable_env,omitempty"`
-	EnableEnvActive  bool   `json:"enable_env_active,omitempty"`
+	Name             string     `json:"name"`
+	Command          string     `json:"command"`
+	Disabled         bool       `json:"disabled"`
+	DisableReason    string     `json:"disable_reason,omitempty"`
+	DisableScope     string     `json:"disable_scope,omitempty"`
+	DisableExpiresAt *time.Time `json:"disable_expires_at,omitempty"`
+	MarkerPath       string     `json:"marker_path,omitempty"`
+	DisableEnv       string     `json:"disable_env,omitempty"`
+	DisableEnvActive bool       `json:"disable_env_active,omitempty"`
+	EnableEnv        string     `json:"enable_env,omitempty"`
+	EnableEnvActive  bool       `json:"enable_env_active,omitempty"`
+
+	Flakiness *corehooks.HookFlakiness `json:"flakiness,omitempty"`
 }
 
 func newListHooksCmd() *cobra.Command {
 	var (
 		repoFlag   string
+		sessionID  string
 		jsonOutput bool
 	)
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List on_stop hooks and their enabled/disabled state",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			root, onStop, err := loadOnStopHooks(repoFlag)
+			if sessionID != "" {
+				if _, err := corehooks.ResolveSessionID(sessionID); err != nil {
+					return err
+				}
+			}
+			root, onStop, _, err := loadOnStopHooks(repoFlag)
 			if err != nil {
 				return err
 			}
@@ -179,13 +347,17 @@ func newListHooksCmd() *cobra.Command {
 				e := hookListEntry{
 					Name:       h.Name,
 					Command:    h.Command,
-					Disabled:   corehooks.IsHookDisabledByMarker(root, h.Name),
 					DisableEnv: h.DisableEnv,
 					EnableEnv:  h.EnableEnv,
 				}
-				if e.Disabled {
-					e.DisableReason = corehooks.HookDisableReason(root, h.Name)
-					e.MarkerPath = corehooks.HookMarkerPath(root, h.Name)
+				if marker, ok := corehooks.FindHookMarker(root, sessionID, h.Name); ok {
+					e.Disabled = true
+					e.DisableReason = marker.Reason
+					e.DisableScope = string(marker.Scope)
+					e.MarkerPath = marker.Path
+					if !marker.ExpiresAt.IsZero() {
+						e.DisableExpiresAt = &marker.ExpiresAt
+					}
 				}
 				if h.DisableEnv != "" && os.Getenv(h.DisableEnv) != "" {
 					e.DisableEnvActive = true
@@ -193,6 +365,9 @@ func newListHooksCmd() *cobra.Command {
 				if h.EnableEnv != "" && os.Getenv(h.EnableEnv) != "" {
 					e.EnableEnvActive = true
 				}
+				if flakiness, ok := corehooks.HookFlakinessFor(root, h.Name); ok {
+					e.Flakiness = &flakiness
+				}
 				entries = append(entries, e)
 			}
 
@@ -217,9 +392,15 @@ func newListHooksCmd() *cobra.Command {
 				note := ""
 				if e.Disabled {
 					state = "disabled"
+					if e.DisableScope != string(corehooks.DisableRepo) {
+						state += " (" + e.DisableScope + ")"
+					}
 					if e.DisableReason != "" {
 						note = "reason: " + e.DisableReason
 					}
+					if e.DisableExpiresAt != nil {
+						note = appendNote(note, formatRemaining(*e.DisableExpiresAt, time.Now()))
+					}
 				}
 				if e.DisableEnv != "" {
 					gate := fmt.Sprintf("disable_env=%s(%s)", e.DisableEnv, envState(e.DisableEnvActive))
@@ -229,12 +410,18 @@ func newListHooksCmd() *cobra.Command {
 					gate := fmt.Sprintf("enable_env=%s(%s)", e.EnableEnv, envState(e.EnableEnvActive))
 					note = appendNote(note, gate)
 				}
+				if f := e.Flakiness; f != nil && f.PassedAfterRetry > 0 {
+					flaky := fmt.Sprintf("flaky: %d/%d runs passed after retry (last %s)",
+						f.PassedAfterRetry, f.Runs, f.LastFlakyAt.Local().Format("2006-01-02 15:04"))
+					note = appendNote(note, flaky)
+				}
 				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, state, truncateHookCmd(e.Command, 40), note)
 			}
 			return tw.Flush()
 		},
 	}
 	cmd.Flags().StringVar(&repoFlag, "repo", "", "Repo directory (defaults to cwd)")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Also show markers scoped to this session")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Emit JSON")
 	return cmd
 }
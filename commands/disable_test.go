@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/spf13/cobra"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+func TestParseDisableUntil(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, loc)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-03-10T18:00:00Z", time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)},
+		{"2026-03-11 09:15", time.Date(2026, 3, 11, 9, 15, 0, 0, loc)},
+		{"2026-03-12", time.Date(2026, 3, 12, 0, 0, 0, 0, loc)},
+		{"18:00", time.Date(2026, 3, 10, 18, 0, 0, 0, loc)},
+		// A time of day already passed means tomorrow.
+		{"09:00", time.Date(2026, 3, 11, 9, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		got, err := parseDisableUntil(tt.in, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if _, err := parseDisableUntil("tomorrow", now); err == nil {
+		t.Error("expected an error for an unparseable time")
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	now := time.Now()
+	for left, want := range map[time.Duration]string{
+		2*time.Hour + 5*time.Minute: "2h05m",
+		42 * time.Minute:            "42m",
+		30 * time.Second:            "30s",
+	} {
+		got := formatRemaining(now.Add(left), now)
+		if got[:len(want)+5] != want+" left" {
+			t.Errorf("formatRemaining(%s) = %q, want prefix %q", left, got, want+" left")
+		}
+	}
+}
+
+func TestDisableAcceptsStopGateNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := t.TempDir()
+	toml := `name = "demo"
+
+[[hooks.on_stop]]
+name = "lint"
+command = "true"
+
+[[hooks.stop_gate]]
+name = "tests"
+command = "go test ./..."
+`
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(cmd func() *cobra.Command, args ...string) error {
+		c := cmd()
+		c.SetArgs(append(args, "--repo", repo))
+		c.SetOut(&bytes.Buffer{})
+		c.SetErr(&bytes.Buffer{})
+		return c.Execute()
+	}
+
+	if err := run(newDisableHookCmd, "tests"); err != nil {
+		t.Fatalf("disable stop gate: %v", err)
+	}
+	if !corehooks.IsHookDisabledByMarker(repo, "tests") {
+		t.Fatal("stop gate not disabled")
+	}
+	if err := run(newEnableHookCmd, "tests"); err != nil {
+		t.Fatalf("enable stop gate: %v", err)
+	}
+	if corehooks.IsHookDisabledByMarker(repo, "tests") {
+		t.Fatal("stop gate still disabled")
+	}
+
+	err := run(newDisableHookCmd, "typo")
+	if err == nil || !strings.Contains(err.Error(), "available: lint, tests") {
+		t.Fatalf("unknown name error = %v", err)
+	}
+}
+
+func TestDisableRejectsUnknownSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := t.TempDir()
+	toml := "name = \"demo\"\n\n[[hooks.on_stop]]\nname = \"lint\"\ncommand = \"true\"\n"
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		cmd  *cobra.Command
+		args []string
+	}{
+		{"disable", newDisableHookCmd(), []string{"lint", "--session", "typo"}},
+		{"list", newListHooksCmd(), []string{"--session", "typo"}},
+	} {
+		tc.cmd.SetArgs(append(tc.args, "--repo", repo))
+		tc.cmd.SetOut(&bytes.Buffer{})
+		tc.cmd.SetErr(&bytes.Buffer{})
+		if err := tc.cmd.Execute(); err == nil || !strings.Contains(err.Error(), `unknown session "typo"`) {
+			t.Errorf("%s --session typo: err = %v", tc.name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(paths.StateDir(), "hooks", "sessions", "typo")); err == nil {
+		t.Error("unknown session got a state dir")
+	}
+}
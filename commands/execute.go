@@ -28,6 +28,15 @@ func Execute() {
 	case "session-start":
 		hooks.RunSessionStartHook()
 		return
+	case "user-prompt-submit":
+		hooks.RunUserPromptSubmitHook()
+		return
+	case "pre-compact":
+		hooks.RunPreCompactHook()
+		return
+	case "session-end":
+		hooks.RunSessionEndHook()
+		return
 	case "subagent-start":
 		hooks.RunSubagentStartHook()
 		return
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+// hookHistoryReport is the history of one repo.
+type hookHistoryReport struct {
+	Repo  string                `json:"repo"`
+	Hooks []corehooks.HookStats `json:"hooks"`
+}
+
+// newHistoryCmd returns `grove hooks history`, which summarizes recorded
+// on_stop hook runs.
+func newHistoryCmd() *cobra.Command {
+	var (
+		repoFlag   string
+		hookFlag   string
+		all        bool
+		jsonOutput bool
+	)
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show pass rate and duration statistics for on_stop hooks",
+		Long: `Show pass rate, p50/p95 duration and the last failure of each on_stop hook,
+from the runs stop-async has recorded for the repo. Hooks are listed slowest
+p95 first. Skipped hooks and cache replays are not recorded.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var files []string
+			if all {
+				matches, err := filepath.Glob(filepath.Join(corehooks.HookHistoryDir(), "*.jsonl"))
+				if err != nil {
+					return err
+				}
+				files = matches
+			} else {
+				start := repoFlag
+				if start == "" {
+					wd, err := os.Getwd()
+					if err != nil {
+						return fmt.Errorf("get cwd: %w", err)
+					}
+					start = wd
+				}
+				root, err := resolveRepoRoot(start)
+				if err != nil {
+					return err
+				}
+				files = []string{corehooks.HookHistoryPath(root)}
+			}
+
+			var reports []hookHistoryReport
+			for _, file := range files {
+				records, err := corehooks.ReadHookHistory(file)
+				if err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("read %s: %w", file, err)
+				}
+				var matched []corehooks.HookRunRecord
+				for _, r := range records {
+					if hookFlag == "" || r.Hook == hookFlag {
+						matched = append(matched, r)
+					}
+				}
+				if len(matched) == 0 {
+					continue
+				}
+				reports = append(reports, hookHistoryReport{
+					Repo:  matched[len(matched)-1].WorkingDir,
+					Hooks: corehooks.SummarizeHookHistory(matched),
+				})
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(reports)
+			}
+			if len(reports) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No on_stop hook runs recorded")
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for i, report := range reports {
+				if i > 0 {
+					fmt.Fprintln(tw)
+				}
+				fmt.Fprintf(tw, "%s\n", report.Repo)
+				fmt.Fprintln(tw, "HOOK\tRUNS\tPASS\tP50\tP95\tLAST FAILURE")
+				for _, s := range report.Hooks {
+					fmt.Fprintf(tw, "%s\t%d\t%.0f%%\t%s\t%s\t%s\n", s.Hook, s.Runs, 100*s.PassRate,
+						s.P50.Round(time.Second), s.P95.Round(time.Second), describeHookFailure(s.LastFailure))
+				}
+			}
+			return tw.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&repoFlag, "repo", "", "Repo directory (defaults to cwd)")
+	cmd.Flags().StringVar(&hookFlag, "hook", "", "Only show this hook")
+	cmd.Flags().BoolVar(&all, "all", false, "Show every repo with recorded runs")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Emit JSON")
+	return cmd
+}
+
+// describeHookFailure renders a failed run as
+// "2026-01-02 15:04 exited 1 (session <id>, <commit>)".
+func describeHookFailure(r *corehooks.HookRunRecord) string {
+	if r == nil {
+		return "-"
+	}
+	outcome := fmt.Sprintf("exited %d", r.ExitCode)
+	if r.Status == "killed" {
+		outcome = "timed out"
+	}
+	desc := r.FinishedAt.Local().Format("2006-01-02 15:04") + " " + outcome
+	session := r.SessionID
+	if session == "" {
+		session = r.NativeSessionID
+	}
+	var context []string
+	if session != "" {
+		context = append(context, "session "+session)
+	}
+	if r.Commit != "" {
+		context = append(context, r.Commit[:min(len(r.Commit), 7)])
+	}
+	if len(context) > 0 {
+		desc += " (" + strings.Join(context, ", ") + ")"
+	}
+	return desc
+}
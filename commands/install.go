@@ -138,10 +138,13 @@ func runInstall(targetDir string, global bool) error {
 	fmt.Println()
 	fmt.Println("The following hooks have been configured:")
 	fmt.Println("  - PreToolUse: Runs before any tool use")
-	fmt.Println("  - PostToolUse: Runs after Edit, Write, MultiEdit, Bash, Read, ExitPlanMode, or Agent tools")
+	fmt.Println("  - PostToolUse: Runs after file, Bash, search, web, ExitPlanMode, Agent, or MCP tools")
 	fmt.Println("  - SessionStart: Runs when a session starts")
+	fmt.Println("  - UserPromptSubmit: Runs when the user submits a prompt")
 	fmt.Println("  - Notification: Runs on notifications")
 	fmt.Println("  - Stop: Runs when conversation stops")
+	fmt.Println("  - PreCompact: Runs before the conversation is compacted")
+	fmt.Println("  - SessionEnd: Runs when a session ends")
 	fmt.Println("  - SubagentStart: Runs when subagent starts")
 	fmt.Println("  - SubagentStop: Runs when subagent stops")
 
@@ -165,7 +168,9 @@ func groveHooksConfig() map[string][]HookEntry {
 				// ExitPlanMode is required for the in-repo plan-preservation
 				// handler (HandleExitPlanMode) to be reachable; Agent enables
 				// spawn-prompt/cost capture for Agent-tool subagent spawns.
-				Matcher: "(Edit|Write|MultiEdit|Bash|Read|ExitPlanMode|Agent)",
+				// The search, notebook, web and MCP tools are included so
+				// post_tool_use reminders can match them.
+				Matcher: "(Edit|Write|MultiEdit|Bash|Read|ExitPlanMode|Agent|Glob|Grep|NotebookEdit|WebFetch|mcp__.*)",
 				Hooks: []Hook{
 					{Type: "command", Command: "grove hooks posttooluse"},
 				},
@@ -179,6 +184,14 @@ func groveHooksConfig() map[string][]HookEntry {
 				},
 			},
 		},
+		"UserPromptSubmit": {
+			{
+				Matcher: ".*",
+				Hooks: []Hook{
+					{Type: "command", Command: "grove hooks user-prompt-submit"},
+				},
+			},
+		},
 		"Notification": {
 			{
 				Matcher: ".*",
@@ -189,9 +202,11 @@ func groveHooksConfig() map[string][]HookEntry {
 		},
 		"Stop": {
 			{
+				// The sync hook runs [[hooks.stop_gate]] commands, which can
+				// outlast Claude Code's 60s default hook timeout.
 				Matcher: ".*",
 				Hooks: []Hook{
-					{Type: "command", Command: "grove hooks stop"},
+					{Type: "command", Command: "grove hooks stop", Timeout: 600},
 				},
 			},
 			// asyncRewake hooks must live in their own matcher block — Claude Code
@@ -203,6 +218,22 @@ func groveHooksConfig() map[string][]HookEntry {
 				},
 			},
 		},
+		"PreCompact": {
+			{
+				Matcher: ".*",
+				Hooks: []Hook{
+					{Type: "command", Command: "grove hooks pre-compact"},
+				},
+			},
+		},
+		"SessionEnd": {
+			{
+				Matcher: ".*",
+				Hooks: []Hook{
+					{Type: "command", Command: "grove hooks session-end"},
+				},
+			},
+		},
 		// SubagentStart accepts command-type hooks only.
 		"SubagentStart": {
 			{
@@ -58,9 +58,12 @@ func TestGroveHooksConfigRegistrations(t *testing.T) {
 
 	// New lifecycle events are registered with command-type hooks.
 	for event, wantCmd := range map[string]string{
-		"SessionStart":  "grove hooks session-start",
-		"SubagentStart": "grove hooks subagent-start",
-		"SubagentStop":  "grove hooks subagent-stop",
+		"SessionStart":     "grove hooks session-start",
+		"UserPromptSubmit": "grove hooks user-prompt-submit",
+		"PreCompact":       "grove hooks pre-compact",
+		"SessionEnd":       "grove hooks session-end",
+		"SubagentStart":    "grove hooks subagent-start",
+		"SubagentStop":     "grove hooks subagent-stop",
 	} {
 		entries, ok := cfg[event]
 		if !ok {
@@ -81,13 +84,14 @@ func TestGroveHooksConfigRegistrations(t *testing.T) {
 		}
 	}
 
-	// PostToolUse matcher must include ExitPlanMode (plan preservation) and
-	// Agent (spawn-prompt/cost capture).
+	// PostToolUse matcher must include ExitPlanMode (plan preservation),
+	// Agent (spawn-prompt/cost capture), and the tools post_tool_use
+	// reminders can match.
 	post := cfg["PostToolUse"]
 	if len(post) != 1 {
 		t.Fatalf("PostToolUse: expected one entry, got %d", len(post))
 	}
-	if got, want := post[0].Matcher, "(Edit|Write|MultiEdit|Bash|Read|ExitPlanMode|Agent)"; got != want {
+	if got, want := post[0].Matcher, "(Edit|Write|MultiEdit|Bash|Read|ExitPlanMode|Agent|Glob|Grep|NotebookEdit|WebFetch|mcp__.*)"; got != want {
 		t.Errorf("PostToolUse matcher = %q, want %q", got, want)
 	}
 }
@@ -148,7 +152,7 @@ func TestMergeHooksUpgradesGroveEntriesAndPreservesUserHooks(t *testing.T) {
 	matchers := entryMatchers(t, post)
 	foundUpgraded := false
 	for _, m := range matchers {
-		if m == "(Edit|Write|MultiEdit|Bash|Read|ExitPlanMode|Agent)" {
+		if m == groveHooksConfig()["PostToolUse"][0].Matcher {
 			foundUpgraded = true
 		}
 		if m == "(Edit|Write|MultiEdit|Bash|Read)" {
@@ -184,8 +188,9 @@ func TestMergeHooksIntoEmptySettings(t *testing.T) {
 		t.Fatal("hooks map not created")
 	}
 	for _, event := range []string{
-		"PreToolUse", "PostToolUse", "SessionStart", "Notification",
-		"Stop", "SubagentStart", "SubagentStop",
+		"PreToolUse", "PostToolUse", "SessionStart", "UserPromptSubmit",
+		"Notification", "Stop", "PreCompact", "SessionEnd", "SubagentStart",
+		"SubagentStop",
 	} {
 		entries, ok := hooksMap[event].([]interface{})
 		if !ok || len(entries) == 0 {
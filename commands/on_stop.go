@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+// onStopFollowInterval is how often --follow polls run.json and the logs.
+const onStopFollowInterval = time.Second
+
+// newOnStopCmd returns `grove hooks on-stop`, which inspects the on_stop hooks
+// run by stop-async.
+func newOnStopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "on-stop",
+		Short: "Inspect on_stop hook runs",
+	}
+	cmd.AddCommand(newOnStopStatusCmd())
+	return cmd
+}
+
+func newOnStopStatusCmd() *cobra.Command {
+	var (
+		sessionID string
+		follow    bool
+		lines     int
+	)
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the progress of the latest on_stop run",
+		Long: `Show the progress of an on_stop run: one header per hook with its state,
+elapsed time against its timeout, and the tail of each running hook's log.
+
+With --follow, new log output is streamed under per-hook headers until the
+run finishes. The command exits 2 if a hook failed or timed out, as
+stop-async does, 1 if stop-async died mid-run, and 0 otherwise. Without
+--follow a run that is still in progress reports the result so far.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			load := func() (*corehooks.OnStopRun, error) {
+				if sessionID != "" {
+					return corehooks.OnStopRunForSession(sessionID)
+				}
+				return corehooks.LatestOnStopRun()
+			}
+			run, err := load()
+			if err != nil {
+				return err
+			}
+
+			p := newOnStopStatusPrinter(cmd.OutOrStdout(), lines)
+			p.print(run, time.Now())
+			for follow && !run.Finished() {
+				time.Sleep(onStopFollowInterval)
+				// Re-read the same run; a later stop replaces run.json.
+				next, err := corehooks.ReadOnStopRun(run.StateDir)
+				if err != nil {
+					return err
+				}
+				if next.RunID != run.RunID {
+					break
+				}
+				run = next
+				p.follow(run, time.Now())
+			}
+			p.result(run)
+
+			if run.Interrupted() {
+				os.Exit(1)
+			}
+			if code := run.ExitCode(); code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&sessionID, "session", "", "Session id (default: the most recent on_stop run)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Stream hook output until the run finishes")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 10, "Log lines to show per running hook")
+	return cmd
+}
+
+// onStopStatusPrinter renders a run and, when following, what changed since
+// the last render.
+type onStopStatusPrinter struct {
+	w     io.Writer
+	lines int
+
+	// Per hook: the state last shown and how much of its log was printed.
+	states  map[string]string
+	offsets map[string]int64
+	// current is the hook whose output was printed last; partial is set
+	// when that output did not end in a newline.
+	current string
+	partial bool
+}
+
+func newOnStopStatusPrinter(w io.Writer, lines int) *onStopStatusPrinter {
+	return &onStopStatusPrinter{
+		w:       w,
+		lines:   lines,
+		states:  map[string]string{},
+		offsets: map[string]int64{},
+	}
+}
+
+// print shows every hook's header and the log tail of running hooks.
+func (p *onStopStatusPrinter) print(run *corehooks.OnStopRun, now time.Time) {
+	fmt.Fprintf(p.w, "on_stop run %s in %s\n", run.RunID, run.WorkingDir)
+	for _, h := range run.Hooks {
+		p.header(run, h, now)
+		p.states[h.Name] = h.State
+		if h.State != corehooks.OnStopRunning {
+			continue
+		}
+		content, _ := os.ReadFile(p.logPath(run, h))
+		p.offsets[h.Name] = int64(len(content))
+		p.current = h.Name
+		for _, line := range lastLines(content, p.lines) {
+			fmt.Fprintf(p.w, "%s\n", line)
+		}
+	}
+}
+
+// follow shows hooks that started or finished and new output from running
+// hooks.
+func (p *onStopStatusPrinter) follow(run *corehooks.OnStopRun, now time.Time) {
+	for _, h := range run.Hooks {
+		if h.State == corehooks.OnStopPending {
+			continue
+		}
+		if p.states[h.Name] != h.State {
+			// A hook that ran between polls still gets its output shown.
+			p.copyLog(run, h, now)
+			if h.State == corehooks.OnStopDone {
+				p.header(run, h, now)
+				p.current = ""
+			}
+			p.states[h.Name] = h.State
+			continue
+		}
+		if h.State == corehooks.OnStopRunning {
+			p.copyLog(run, h, now)
+		}
+	}
+}
+
+// copyLog prints a hook's log output since the last call, preceded by its
+// header when another hook's output was printed in between.
+func (p *onStopStatusPrinter) copyLog(run *corehooks.OnStopRun, h corehooks.OnStopHookState, now time.Time) {
+	content, err := os.ReadFile(p.logPath(run, h))
+	if err != nil {
+		return
+	}
+	offset := p.offsets[h.Name]
+	if int64(len(content)) < offset {
+		// The log was truncated by a new attempt.
+		offset = 0
+	}
+	if int64(len(content)) == offset {
+		return
+	}
+	if p.current != h.Name {
+		running := h
+		running.State = corehooks.OnStopRunning
+		p.header(run, running, now)
+		p.current = h.Name
+	}
+	_, _ = p.w.Write(content[offset:])
+	p.partial = content[len(content)-1] != '\n'
+	p.offsets[h.Name] = int64(len(content))
+}
+
+// header prints a hook's one-line state, e.g. "==> lint ⏳ 42s / 600s".
+func (p *onStopStatusPrinter) header(run *corehooks.OnStopRun, h corehooks.OnStopHookState, now time.Time) {
+	seconds := int(h.Elapsed(run.StateDir, now).Seconds())
+	var state string
+	switch {
+	case h.State == corehooks.OnStopPending:
+		state = "… pending"
+	case h.State == corehooks.OnStopRunning && run.Interrupted():
+		state = fmt.Sprintf("✗ interrupted after %ds", seconds)
+	case h.State == corehooks.OnStopRunning:
+		state = fmt.Sprintf("⏳ %ds / %ds", seconds, h.Timeout)
+	case h.Status == "killed":
+		state = fmt.Sprintf("✗ timed out after %ds", h.Timeout)
+	case h.Failed():
+		state = fmt.Sprintf("✗ %s (%ds)", h.Status, seconds)
+	case strings.HasPrefix(h.Status, "skipped"):
+		state = "- " + h.Status
+	default:
+		state = fmt.Sprintf("✓ %s (%ds)", h.Status, seconds)
+	}
+	if p.partial {
+		fmt.Fprintln(p.w)
+		p.partial = false
+	}
+	fmt.Fprintf(p.w, "==> %s %s\n", h.Name, state)
+}
+
+// result prints the run's aggregate outcome.
+func (p *onStopStatusPrinter) result(run *corehooks.OnStopRun) {
+	if p.partial {
+		fmt.Fprintln(p.w)
+		p.partial = false
+	}
+	failed := 0
+	for _, h := range run.Hooks {
+		if h.Failed() {
+			failed++
+		}
+	}
+	switch {
+	case run.Interrupted():
+		fmt.Fprintf(p.w, "stop-async (pid %d) exited before the run finished\n", run.PID)
+	case !run.Finished():
+		fmt.Fprintf(p.w, "still running; %d hook(s) failed so far\n", failed)
+	case failed > 0:
+		fmt.Fprintf(p.w, "%d hook(s) failed\n", failed)
+	default:
+		fmt.Fprintln(p.w, "no hook failed")
+	}
+}
+
+func (p *onStopStatusPrinter) logPath(run *corehooks.OnStopRun, h corehooks.OnStopHookState) string {
+	return filepath.Join(run.StateDir, h.Slug()+".log")
+}
+
+// lastLines returns up to n trailing lines of content.
+func lastLines(content []byte, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	all := bytes.Split(bytes.TrimRight(content, "\n"), []byte("\n"))
+	if len(all) == 1 && len(all[0]) == 0 {
+		return nil
+	}
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	lines := make([]string, len(all))
+	for i, line := range all {
+		lines[i] = string(line)
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+func TestOnStopStatusPrinter(t *testing.T) {
+	stateDir := t.TempDir()
+	now := time.Now()
+	logPath := filepath.Join(stateDir, "lint.log")
+	if err := os.WriteFile(logPath, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := &corehooks.OnStopRun{
+		RunID:    "on_stop_1",
+		PID:      os.Getpid(),
+		StateDir: stateDir,
+		Hooks: []corehooks.OnStopHookState{
+			{Name: "lint", State: corehooks.OnStopRunning, Timeout: 600, StartedAt: now.Add(-42 * time.Second)},
+			{Name: "tests", State: corehooks.OnStopDone, Status: "passed", StartedAt: now.Add(-5 * time.Second), FinishedAt: now},
+			{Name: "e2e", State: corehooks.OnStopPending},
+		},
+	}
+
+	var out bytes.Buffer
+	p := newOnStopStatusPrinter(&out, 2)
+	p.print(run, now)
+	for _, want := range []string{"==> lint ⏳ 42s / 600s\ntwo\nthree\n", "==> tests ✓ passed (5s)", "==> e2e … pending"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Following prints only new output, then the hook's result.
+	out.Reset()
+	if err := os.WriteFile(logPath, []byte("one\ntwo\nthree\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run.Hooks[0] = corehooks.OnStopHookState{Name: "lint", State: corehooks.OnStopDone, Status: "failed", Timeout: 600, StartedAt: now.Add(-42 * time.Second), FinishedAt: now}
+	run.FinishedAt = now
+	p.follow(run, now)
+	p.result(run)
+	want := "four\n==> lint ✗ failed (42s)\n1 hook(s) failed\n"
+	if out.String() != want {
+		t.Errorf("follow output = %q, want %q", out.String(), want)
+	}
+	if run.ExitCode() != 2 {
+		t.Errorf("ExitCode = %d, want 2", run.ExitCode())
+	}
+}
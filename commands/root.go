@@ -21,6 +21,8 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newStopCmd())
 	rootCmd.AddCommand(NewStopAsyncCmd())
 	rootCmd.AddCommand(newSessionStartCmd())
+	rootCmd.AddCommand(newUserPromptSubmitCmd())
+	rootCmd.AddCommand(newPreCompactCmd())
 	rootCmd.AddCommand(newSessionStatusCmd())
 	rootCmd.AddCommand(newSessionEndCmd())
 	rootCmd.AddCommand(newSubagentStartCmd())
@@ -35,6 +37,10 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newDisableHookCmd())
 	rootCmd.AddCommand(newEnableHookCmd())
 	rootCmd.AddCommand(newListHooksCmd())
+	rootCmd.AddCommand(newCacheCmd())
+	rootCmd.AddCommand(newOnStopCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+	rootCmd.AddCommand(newRunCmd())
 
 	tuiCmd := NewBrowseCmd()
 	tuiCmd.Use = "tui"
@@ -94,6 +100,37 @@ func newSessionStartCmd() *cobra.Command {
 	}
 }
 
+func newUserPromptSubmitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "user-prompt-submit",
+		Short: "Run the user prompt submit hook",
+		Long: `Run the user-prompt-submit hook.
+
+Marks the session running, records the prompt in the session's
+prompts.jsonl, and applies [[hooks.user_prompt_submit]] rules from
+grove.toml: matching prompts can be blocked or have additional context
+injected alongside them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			hooks.RunUserPromptSubmitHook()
+		},
+	}
+}
+
+func newPreCompactCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pre-compact",
+		Short: "Run the pre-compact hook",
+		Long: `Run the pre-compact hook.
+
+Snapshots the transcript tail and the plan/job context into the job's
+.artifacts before compaction, and emits [hooks.pre_compact] instructions
+from grove.toml.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			hooks.RunPreCompactHook()
+		},
+	}
+}
+
 func newSessionStatusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "session-status",
@@ -112,12 +149,16 @@ report non-terminal status transitions into the grove session pipeline.`,
 func newSessionEndCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "session-end",
-		Short: "Run the session end hook (provider integrations)",
+		Short: "Run the session end hook",
 		Long: `Run the session-end hook.
 
 Provider integrations (the opencode plugin) pipe a JSON payload
 ({"session_id": ..., "reason": "deleted"}) when the provider destroyed the
-session. The session is marked completed and its registry entry removed.`,
+session. The session is marked completed and its registry entry removed.
+
+Claude Code's SessionEnd payload also carries transcript_path. Those
+sessions are finalized in place: terminal status in the daemon and
+metadata.json, registry entry kept for transcript archiving.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			hooks.RunSessionEndHook()
 		},
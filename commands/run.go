@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corehooks "github.com/grovetools/hooks/internal/hooks"
+)
+
+// newRunCmd returns `grove hooks run`, which runs the on_stop hooks outside
+// a Stop event.
+func newRunCmd() *cobra.Command {
+	var (
+		repoFlag   string
+		sessionID  string
+		dryRun     bool
+		jsonOutput bool
+	)
+	cmd := &cobra.Command{
+		Use:   "run [hook-name...]",
+		Short: "Run on_stop hooks now, or show which would run",
+		Long: `Run the repo's [[hooks.on_stop]] hooks (all of them, or the named ones)
+as stop-async would run them, writing the same artifacts, and print each
+hook's result; skipped hooks show why. The command exits 2 if any failed.
+
+With --dry-run nothing runs: each hook is evaluated against its gates —
+disable markers, enable_env/disable_env and the run_if conditions — and the
+command prints why it would or would not run.
+
+With --session the run uses that session's job, type and provider for the
+conditions and writes to its on_stop directory, so ` + "`grove hooks on-stop status`" + `
+and the session browser show it; the session must exist. When only some
+hooks are named, needs on the others are ignored.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start := repoFlag
+			if start == "" {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("get cwd: %w", err)
+				}
+				start = wd
+			}
+			root, err := resolveRepoRoot(start)
+			if err != nil {
+				return err
+			}
+			hooksConfig, err := corehooks.LoadOnStopConfig(root)
+			if err != nil {
+				return err
+			}
+			if len(hooksConfig.OnStop) == 0 {
+				return fmt.Errorf("no [[hooks.on_stop]] entries in %s", root)
+			}
+			selected, err := corehooks.SelectOnStopHooks(hooksConfig.OnStop, args)
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				plan, err := corehooks.PlanOnStopHooks(root, sessionID, selected)
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					enc := json.NewEncoder(cmd.OutOrStdout())
+					enc.SetIndent("", "  ")
+					return enc.Encode(plan)
+				}
+				for _, entry := range plan {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", entry.Name, describePlanEntry(entry))
+				}
+				return nil
+			}
+
+			// Gates are evaluated by the run itself: run_if_command
+			// predicates must not run twice.
+			stateDir, code, err := corehooks.RunOnStopHooks(root, sessionID, selected, hooksConfig.MaxParallel)
+			if err != nil {
+				return err
+			}
+			if run, err := corehooks.ReadOnStopRun(stateDir); err == nil {
+				p := newOnStopStatusPrinter(cmd.OutOrStdout(), 0)
+				p.print(run, time.Now())
+				p.result(run)
+			}
+			if code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repoFlag, "repo", "", "Repo directory (defaults to cwd)")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Run for this session (provider or grove session id)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show which hooks would run and why")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Emit the dry-run plan as JSON")
+	return cmd
+}
+
+// describePlanEntry renders a plan entry, e.g. "would run after build" or
+// "would skip (disabled)".
+func describePlanEntry(entry corehooks.OnStopPlanEntry) string {
+	if !entry.Run {
+		return "would skip (" + entry.Reason + ")"
+	}
+	desc := "would run"
+	if len(entry.Needs) > 0 {
+		desc += " after " + strings.Join(entry.Needs, ", ")
+	}
+	if entry.Reason != "" {
+		desc += " (" + entry.Reason + ")"
+	}
+	return desc
+}
@@ -1,6 +1,7 @@
 package hooks
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,12 +12,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/grovetools/core/config"
 	"github.com/grovetools/core/errors"
+	"github.com/grovetools/core/pkg/daemon"
 	"github.com/grovetools/core/pkg/paths"
 	"github.com/grovetools/core/pkg/process"
 )
@@ -41,9 +42,9 @@ func slugifyHookName(name string) string {
 }
 
 // RunStopAsyncHook is the entry point for the `grove hooks stop-async` command.
-// It reads stop input from stdin, loads the repo's grove.toml, and runs each
-// [[hooks.on_stop]] command in parallel. Per-hook artifacts (pid lockfile,
-// log, summary) are stored under StateDir()/hooks/sessions/<session_id>/on_stop.
+// It reads stop input from stdin, loads the repo's grove.toml, and runs the
+// [[hooks.on_stop]] commands in dependency order (see on_stop_dag.go). Per-hook artifacts (pid lockfile,
+// log, summary) and the run's run.json are stored under StateDir()/hooks/sessions/<session_id>/on_stop.
 // Any hook that exits non-zero (or times out) causes stop-async to exit 2 with
 // aggregated stderr, so Claude Code's asyncRewake surfaces the failure to the
 // agent. If all hooks exit 0, stop-async exits 0 and the session stays stopped.
@@ -76,7 +77,7 @@ func RunStopAsyncHook() {
 		os.Exit(1)
 	}
 
-	var hooksConfig config.HooksConfig
+	var hooksConfig OnStopConfig
 	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
 		fmt.Fprintf(os.Stderr, "stop-async: unmarshal hooks config: %v\n", err)
 		os.Exit(1)
@@ -90,13 +91,15 @@ func RunStopAsyncHook() {
 	if sessionID == "" {
 		sessionID = "unknown"
 	}
-	stateDir := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID, "on_stop")
+	stateDir := OnStopStateDir(sessionID)
 	if err := os.MkdirAll(stateDir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "stop-async: create state dir: %v\n", err)
 		os.Exit(1)
 	}
 
-	exitCode := executeAsyncHooks(hooksConfig.OnStop, workingDir, stateDir)
+	stop := newOnStopContext(data, workingDir)
+	stop.Daemon = daemon.New()
+	exitCode := executeAsyncHooks(hooksConfig.OnStop, hooksConfig.MaxParallel, stop, stateDir)
 	os.Exit(exitCode)
 }
 
@@ -125,46 +128,58 @@ func resolveAsyncWorkingDir(data StopInput) string {
 	return ""
 }
 
-// executeAsyncHooks runs all configured on_stop hooks in parallel and returns
-// the aggregated exit code (2 if any hook exited 2, otherwise 0).
-func executeAsyncHooks(cmds []config.HookCommand, workingDir, stateDir string) int {
-	var (
-		wg           sync.WaitGroup
-		stderrMu     sync.Mutex
-		blockingErrs []string
-	)
-
-	for _, hc := range cmds {
-		hc := hc
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			stderr, exited2 := runSingleAsyncHook(hc, workingDir, stateDir)
-			if exited2 {
-				stderrMu.Lock()
-				if stderr == "" {
-					stderr = fmt.Sprintf("hook %q exited 2", hc.Name)
-				}
-				blockingErrs = append(blockingErrs, stderr)
-				stderrMu.Unlock()
-			}
-		}()
-	}
-	wg.Wait()
+// asyncHookResult is the outcome of one on_stop hook. Status is the line
+// recorded in the .summary file; Stderr is populated when the hook blocks.
+// Gated marks a hook skipped by its own gating (marker, env, run_if), which
+// does not hold back hooks that need it.
+type asyncHookResult struct {
+	Status   string
+	Stderr   string
+	Blocking bool
+	Gated    bool
+}
 
-	if len(blockingErrs) > 0 {
-		fmt.Fprint(os.Stderr, strings.Join(blockingErrs, "\n\n"))
-		if !strings.HasSuffix(blockingErrs[len(blockingErrs)-1], "\n") {
-			fmt.Fprintln(os.Stderr)
+// skippedHook records a skip with its reason in the hook's summary.
+func skippedHook(summaryPath, reason string) asyncHookResult {
+	status := "skipped: " + reason
+	appendSummary(summaryPath, status)
+	return asyncHookResult{Status: status}
+}
+
+// gatedHook records a skip caused by the hook's own gating.
+func gatedHook(summaryPath, reason string) asyncHookResult {
+	r := skippedHook(summaryPath, reason)
+	r.Gated = true
+	return r
+}
+
+// gateReason returns why the hook's own gating skips it, or "" when it runs.
+func (c *onStopContext) gateReason(hc OnStopHook) string {
+	// marker-file gating: a `grove hooks disable` marker wins over env-var
+	// checks so operators can toggle hooks while a Claude session is live
+	// (env vars are captured at Claude Code startup and can't be changed).
+	if marker, ok := FindHookMarker(c.WorkingDir, c.NativeSessionID, hc.Name); ok {
+		if marker.Scope == DisableRepo {
+			return "disabled"
 		}
-		return 2
+		return fmt.Sprintf("disabled (%s)", marker.Scope)
+	}
+
+	// env-var gating: explicit disable wins, then opt-in via enable_env.
+	if hc.DisableEnv != "" && os.Getenv(hc.DisableEnv) != "" {
+		return hc.DisableEnv + " is set"
 	}
-	return 0
+	if hc.EnableEnv != "" && os.Getenv(hc.EnableEnv) == "" {
+		return hc.EnableEnv + " is not set"
+	}
+
+	// run_if gating (run_if.go)
+	return c.skipReason(hc)
 }
 
 // runSingleAsyncHook executes one hook, writing its pid/log/summary artifacts.
-// Returns (stderr, exited2): stderr is populated when the hook exited 2.
-func runSingleAsyncHook(hc config.HookCommand, workingDir, stateDir string) (string, bool) {
+func runSingleAsyncHook(hc OnStopHook, stop *onStopContext, stateDir string) asyncHookResult {
+	workingDir := stop.WorkingDir
 	slug := slugifyHookName(hc.Name)
 	pidPath := filepath.Join(stateDir, slug+".pid")
 	logPath := filepath.Join(stateDir, slug+".log")
@@ -173,7 +188,9 @@ func runSingleAsyncHook(hc config.HookCommand, workingDir, stateDir string) (str
 	if hc.CancelPrevious {
 		if b, err := os.ReadFile(pidPath); err == nil {
 			if oldPid, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil && oldPid > 0 {
-				if process.IsProcessAlive(oldPid) {
+				// The old run leads its own process group (process_group.go);
+				// a pid from before that was signalled on its own.
+				if !terminateProcessGroup(oldPid, hc.killGrace()) && process.IsProcessAlive(oldPid) {
 					_ = syscall.Kill(oldPid, syscall.SIGTERM)
 					// Give the old process a brief moment to exit.
 					for i := 0; i < 20; i++ {
@@ -187,107 +204,208 @@ func runSingleAsyncHook(hc config.HookCommand, workingDir, stateDir string) (str
 		}
 	}
 
-	// marker-file gating: a `grove hooks disable` marker wins over env-var
-	// checks so operators can toggle hooks while a Claude session is live
-	// (env vars are captured at Claude Code startup and can't be changed).
-	if IsHookDisabledByMarker(workingDir, hc.Name) {
-		appendSummary(summaryPath, "skipped")
-		return "", false
-	}
-
-	// env-var gating: explicit disable wins, then opt-in via enable_env.
-	if hc.DisableEnv != "" && os.Getenv(hc.DisableEnv) != "" {
-		appendSummary(summaryPath, "skipped")
-		return "", false
-	}
-	if hc.EnableEnv != "" && os.Getenv(hc.EnableEnv) == "" {
-		appendSummary(summaryPath, "skipped")
-		return "", false
+	if reason := stop.gateReason(hc); reason != "" {
+		return gatedHook(summaryPath, reason)
 	}
 
-	// run_if gating
-	if hc.RunIf == "changes" {
-		hasChanges, err := hasGitChanges(workingDir)
-		if err != nil || !hasChanges {
-			appendSummary(summaryPath, "skipped")
-			return "", false
+	// Replay a cached outcome for an unchanged tree (cache.go).
+	cacheKey := ""
+	if hc.Cache {
+		if key, err := hookCacheKey(hc, workingDir); err == nil {
+			cacheKey = key
+			if entry, ok := loadHookCache(slug, key); ok {
+				return replayHookCache(slug, key, entry, logPath, summaryPath)
+			}
 		}
 	}
 
 	// Write our PID to the lockfile; remove on exit.
 	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil { //nolint:gosec // pid file
-		return "", false
+		return asyncHookResult{}
 	}
 	defer os.Remove(pidPath)
 
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
 	if err != nil {
-		return "", false
+		return asyncHookResult{}
 	}
 	defer logFile.Close()
 
-	timeout := hc.Timeout
-	if timeout <= 0 {
-		timeout = defaultAsyncHookTimeout
-	}
+	timeout := hc.hookTimeout()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
-	defer cancel()
+	// Changed files since this hook last passed (incremental.go).
+	checkpointPath := filepath.Join(stateDir, slug+".last_pass")
+	checkpoint := hookCheckpoint{StartedAt: time.Now(), Head: gitOutput(workingDir, "rev-parse", "HEAD")}
+	changedFiles := stop.changedFilesSince(readHookCheckpoint(checkpointPath))
+	changedFilesPath, err := writeChangedFilesList(changedFiles)
+	if err == nil {
+		defer os.Remove(changedFilesPath)
+	}
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", hc.Command) //nolint:gosec // command from trusted config
-	cmd.Dir = workingDir
+	env := append(os.Environ(), stop.hookEnv(changedFilesPath, changedFiles)...)
+	argv, err := hookArgv(hc)
+	if err != nil {
+		fmt.Fprintf(logFile, "%v\n", err)
+		appendSummary(summaryPath, "failed")
+		return asyncHookResult{Status: "failed", Stderr: fmt.Sprintf("hook %q: %v", hc.Name, err), Blocking: true}
+	}
 
 	// Capture combined stdout+stderr to the log file AND a stderr-only buffer
 	// so we can surface exit-2 errors back to Claude Code for rewake.
 	var stderrBuf strings.Builder
-	cmd.Stdout = logFile
-	cmd.Stderr = io.MultiWriter(logFile, &stderrBuf)
-
-	if err := cmd.Start(); err != nil {
-		appendSummary(summaryPath, "failed")
-		return "", false
+	stdout := io.Writer(logFile)
+	stderr := io.MultiWriter(logFile, &stderrBuf)
+	// A parser also needs stdout on its own (output_parsers.go).
+	var stdoutBuf bytes.Buffer
+	if hc.Parser != "" {
+		stdout = io.MultiWriter(logFile, &stdoutBuf)
 	}
-	// Overwrite the lockfile with the child's PID so cancel_previous targets
-	// the actual hook process rather than the short-lived grove-hooks parent.
-	_ = os.WriteFile(pidPath, []byte(strconv.Itoa(cmd.Process.Pid)), 0o644) //nolint:gosec // pid file
 
-	runErr := cmd.Wait()
-
-	status := "passed"
-	blocking := false
-	exitCode := 0
-	if ctx.Err() == context.DeadlineExceeded {
-		status = "killed"
-		blocking = true
-		exitCode = -1
-	} else if runErr != nil {
-		status = "failed"
-		blocking = true
-		if exitError, ok := runErr.(*exec.ExitError); ok {
-			if ws, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				exitCode = ws.ExitStatus()
-			}
+	// Failed attempts are retried per retries/retry_on_exit_codes (retry.go);
+	// each attempt is marked in the log and only the last one's output is
+	// reported.
+	attempts := 1 + max(hc.Retries, 0)
+	var status string
+	var exitCode int
+	attempt := 1
+	for ; ; attempt++ {
+		if attempts > 1 {
+			fmt.Fprintf(logFile, "=== attempt %d/%d ===\n", attempt, attempts)
 		}
+		stderrBuf.Reset()
+		stdoutBuf.Reset()
+		if hc.Parser != "" && hc.ReportFile != "" {
+			// A tool that dies before writing its report must not leave the
+			// previous run's findings to be parsed.
+			_ = os.Remove(hc.reportPath(workingDir))
+		}
+		status, exitCode, err = runHookAttempt(argv, workingDir, env, timeout, hc.killGrace(), stdout, stderr, pidPath)
+		if err != nil {
+			appendSummary(summaryPath, "failed")
+			return asyncHookResult{Status: "failed"}
+		}
+		if attempt == attempts || !hc.retryable(status, exitCode) {
+			break
+		}
+		fmt.Fprintf(logFile, "=== attempt %d/%d exited %d; retrying ===\n", attempt, attempts, exitCode)
 	}
+	if status == "passed" && attempt > 1 {
+		status = statusPassedAfterRetry
+	}
+	blocking := !hookPassed(status)
+	recordHookRun(workingDir, hc.Name, status)
+	appendHookHistory(HookRunRecord{
+		Hook:            hc.Name,
+		WorkingDir:      workingDir,
+		SessionID:       stop.SessionID,
+		NativeSessionID: stop.NativeSessionID,
+		RunID:           stop.RunID,
+		StartedAt:       checkpoint.StartedAt.UTC(),
+		FinishedAt:      time.Now().UTC(),
+		Status:          status,
+		ExitCode:        exitCode,
+		Attempts:        attempt,
+		LogPath:         logPath,
+		Commit:          checkpoint.Head,
+	})
 
 	appendSummary(summaryPath, status)
+	if hookPassed(status) {
+		writeHookCheckpoint(checkpointPath, checkpoint)
+	}
 
+	result := asyncHookResult{Status: status}
+	header := fmt.Sprintf("hook %q failed", hc.Name)
+	if status == "killed" {
+		header = fmt.Sprintf("hook %q timed out after %ds", hc.Name, timeout)
+	} else if exitCode != 0 {
+		header = fmt.Sprintf("hook %q exited %d", hc.Name, exitCode)
+	}
 	if blocking {
 		msg := strings.TrimSpace(stderrBuf.String())
-		header := fmt.Sprintf("hook %q failed", hc.Name)
-		if status == "killed" {
-			header = fmt.Sprintf("hook %q timed out after %ds", hc.Name, timeout)
-		} else if exitCode != 0 {
-			header = fmt.Sprintf("hook %q exited %d", hc.Name, exitCode)
-		}
 		if msg == "" {
 			msg = header
 		} else {
 			msg = header + ":\n" + msg
 		}
-		return msg, true
+		result = asyncHookResult{Status: status, Stderr: msg, Blocking: true}
+	}
+
+	if hc.Parser != "" {
+		doc := hookFindings{Parser: hc.Parser, Status: status}
+		findings, err := parseHookOutput(hc, workingDir, stdoutBuf.Bytes(), []byte(stderrBuf.String()))
+		if err != nil {
+			doc.ParseError = err.Error()
+		}
+		doc.Findings = findings
+		writeHookFindings(filepath.Join(stateDir, slug+".findings.json"), doc)
+		if blocking && len(findings) > 0 {
+			result.Stderr = formatHookFindings(header, hc.Parser, findings, logPath)
+		}
+	}
+
+	if cacheKey != "" && status != "killed" {
+		_ = storeHookCache(slug, cacheKey, hookCacheEntry{
+			Status:     status,
+			Stderr:     result.Stderr,
+			Command:    hc.Command,
+			WorkingDir: workingDir,
+			RecordedAt: time.Now().UTC(),
+		}, logPath)
+	}
+	return result
+}
+
+// runHookAttempt runs the hook once in its own process group and reports
+// "passed", "failed" or "killed" (timed out) with the exit code (-1 when
+// killed). err is set only when the command could not be started.
+func runHookAttempt(argv []string, workingDir string, env []string, timeout int, grace time.Duration, stdout, stderr io.Writer, pidPath string) (status string, exitCode int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // command from trusted config
+	cmd.Dir = workingDir
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	swept := false
+	cmd.Cancel = func() error {
+		// The leader is still running, so its pid is still our group's.
+		swept = true
+		terminateProcessGroup(cmd.Process.Pid, grace)
+		return nil
+	}
+	// Stop waiting on output pipes still held by stragglers in the group,
+	// which are then terminated below.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Start(); err != nil {
+		return "", 0, err
+	}
+	// Overwrite the lockfile with the child's PID so cancel_previous targets
+	// the actual hook process rather than the short-lived grove-hooks parent.
+	_ = os.WriteFile(pidPath, []byte(strconv.Itoa(cmd.Process.Pid)), 0o644) //nolint:gosec // pid file
+
+	runErr := cmd.Wait()
+	// Whatever the hook left running in its group goes with it. The leader is
+	// reaped by now; a group with members left cannot have its id reused, and
+	// an empty one is not signalled at all.
+	if !swept && processGroupExists(cmd.Process.Pid) {
+		terminateProcessGroup(cmd.Process.Pid, grace)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return "killed", -1, nil
+	}
+	if runErr != nil && runErr != exec.ErrWaitDelay { //nolint:errorlint // Wait returns it unwrapped
+		if exitError, ok := runErr.(*exec.ExitError); ok {
+			if ws, ok := exitError.Sys().(syscall.WaitStatus); ok {
+				exitCode = ws.ExitStatus()
+			}
+		}
+		return "failed", exitCode, nil
 	}
-	return "", false
+	return "passed", 0, nil
 }
 
 // appendSummary adds a timestamped line to the hook's summary file.
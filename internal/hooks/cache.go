@@ -0,0 +1,187 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+// on_stop result cache: a hook with cache = true records its outcome keyed on
+// its command and the state of the repository, and a later stop with the same
+// key replays the recorded pass/fail and log instead of running it again.
+//
+//	[[hooks.on_stop]]
+//	name = "test"
+//	command = "go test ./..."
+//	cache = true
+//	cache_inputs = ["**/*.go", "go.mod", "go.sum"]  # default: every file
+//
+// The tree state hashes the index entries of tracked files plus the contents
+// of modified and untracked (non-ignored) files, restricted to cache_inputs
+// when set (repo-relative globs as for changed_paths). The key is computed
+// before the hook runs, so a hook that rewrites files is keyed on what it
+// saw. Passes and failures are cached; timeouts are not. A replay's summary
+// line reads "passed (cached)" or "failed (cached)". Entries live under
+// StateDir()/hooks/cache and `grove hooks cache clear` removes them.
+
+// hookCacheEntry is the recorded outcome of a cached hook run.
+type hookCacheEntry struct {
+	Status     string    `json:"status"`
+	Stderr     string    `json:"stderr,omitempty"`
+	Command    string    `json:"command"`
+	WorkingDir string    `json:"working_dir"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// HookCacheDir is the root of the on_stop result cache.
+func HookCacheDir() string {
+	return filepath.Join(paths.StateDir(), "hooks", "cache")
+}
+
+// ClearHookCache removes every cached result and returns how many were
+// removed.
+func ClearHookCache() (int, error) {
+	entries, err := filepath.Glob(filepath.Join(HookCacheDir(), "*", "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(HookCacheDir()); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// hookTreeHash hashes the repository state of workingDir's repo, limited to
+// files matching inputs when any are given.
+func hookTreeHash(workingDir string, inputs []string) (string, error) {
+	root := gitOutput(workingDir, "rev-parse", "--show-toplevel")
+	if root == "" {
+		return "", fmt.Errorf("%s is not in a git repository", workingDir)
+	}
+	git := func(args ...string) ([]string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+		return strings.Split(strings.TrimRight(string(output), "\x00"), "\x00"), nil
+	}
+
+	state := map[string]string{}
+	staged, err := git("ls-files", "-s", "-z")
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range staged {
+		// "<mode> <object> <stage>\t<path>"
+		info, path, ok := strings.Cut(entry, "\t")
+		if ok {
+			state[path] = info
+		}
+	}
+	worktree, err := git("ls-files", "-m", "-o", "--exclude-standard", "-z")
+	if err != nil {
+		return "", err
+	}
+	for _, path := range worktree {
+		if path == "" {
+			continue
+		}
+		state[path] = "deleted"
+		if f, err := os.Open(filepath.Join(root, path)); err == nil {
+			h := sha256.New()
+			if _, err := io.Copy(h, f); err == nil {
+				state[path] = "worktree " + hex.EncodeToString(h.Sum(nil))
+			}
+			f.Close()
+		}
+	}
+
+	files := make([]string, 0, len(state))
+	for path := range state {
+		if len(inputs) == 0 || slices.ContainsFunc(inputs, func(pattern string) bool {
+			return matchGlob(pattern, path, true)
+		}) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, path := range files {
+		fmt.Fprintf(h, "%s\x00%s\n", path, state[path])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hookCacheKey keys a hook run on its working dir, command and tree state.
+func hookCacheKey(hc OnStopHook, workingDir string) (string, error) {
+	tree, err := hookTreeHash(workingDir, hc.CacheInputs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(workingDir + "\x00" + hc.Command + "\x00" + tree))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// hookCachePaths returns the entry and log paths for a cache key.
+func hookCachePaths(slug, key string) (entryPath, logPath string) {
+	base := filepath.Join(HookCacheDir(), slug, key)
+	return base + ".json", base + ".log"
+}
+
+// loadHookCache returns the cached outcome for key, if any.
+func loadHookCache(slug, key string) (hookCacheEntry, bool) {
+	var entry hookCacheEntry
+	entryPath, _ := hookCachePaths(slug, key)
+	content, err := os.ReadFile(entryPath)
+	if err != nil || json.Unmarshal(content, &entry) != nil || entry.Status == "" {
+		return entry, false
+	}
+	return entry, true
+}
+
+// storeHookCache records an outcome and a copy of its log under key.
+func storeHookCache(slug, key string, entry hookCacheEntry, logPath string) error {
+	entryPath, cachedLogPath := hookCachePaths(slug, key)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		return err
+	}
+	if log, err := os.ReadFile(logPath); err == nil {
+		if err := os.WriteFile(cachedLogPath, log, 0o644); err != nil {
+			return err
+		}
+	}
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(entryPath, content, 0o644)
+}
+
+// replayHookCache writes a cached outcome into the hook's log and summary.
+func replayHookCache(slug, key string, entry hookCacheEntry, logPath, summaryPath string) asyncHookResult {
+	_, cachedLogPath := hookCachePaths(slug, key)
+	log, _ := os.ReadFile(cachedLogPath)
+	header := fmt.Sprintf("# replayed from cache: %s at %s\n", entry.Status, entry.RecordedAt.UTC().Format(time.RFC3339))
+	_ = os.WriteFile(logPath, append([]byte(header), log...), 0o644)
+	appendSummary(summaryPath, entry.Status+" (cached)")
+
+	result := asyncHookResult{Status: entry.Status}
+	if !hookPassed(entry.Status) {
+		result.Blocking = true
+		result.Stderr = entry.Stderr
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookTreeHash(t *testing.T) {
+	repo := initGitRepo(t)
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func(inputs ...string) string {
+		t.Helper()
+		h, err := hookTreeHash(repo, inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	write("main.go", "package main\n")
+	all, goOnly := hash(), hash("**/*.go")
+	if again := hash(); again != all {
+		t.Fatal("tree hash is not stable")
+	}
+
+	write("README.md", "docs\n")
+	if hash() == all {
+		t.Fatal("untracked file did not change the full tree hash")
+	}
+	if hash("**/*.go") != goOnly {
+		t.Fatal("docs change altered a **/*.go-scoped hash")
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	if hash("**/*.go") == goOnly {
+		t.Fatal("edit to main.go did not change the scoped hash")
+	}
+
+	if _, err := hookTreeHash(t.TempDir(), nil); err == nil {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
+
+func TestRunSingleAsyncHookReplaysCache(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	repo := initGitRepo(t)
+	stateDir := t.TempDir()
+	counter := filepath.Join(t.TempDir(), "runs")
+
+	hook := onStopHook("test", "echo run >> "+counter+"; echo output; exit 1")
+	hook.Cache = true
+	stop := &onStopContext{WorkingDir: repo}
+
+	first := runSingleAsyncHook(hook, stop, stateDir)
+	second := runSingleAsyncHook(hook, stop, stateDir)
+	if first.Status != "failed" || second.Status != "failed" || !second.Blocking || second.Stderr != first.Stderr {
+		t.Fatalf("results = %+v, %+v", first, second)
+	}
+	if runs, _ := os.ReadFile(counter); strings.Count(string(runs), "run") != 1 {
+		t.Fatalf("hook ran %d times, want 1", strings.Count(string(runs), "run"))
+	}
+	if got := lastSummary(t, stateDir, "test"); got != "failed (cached)" {
+		t.Fatalf("summary = %q", got)
+	}
+	if log, _ := os.ReadFile(filepath.Join(stateDir, "test.log")); !strings.Contains(string(log), "output") {
+		t.Fatalf("replayed log = %q", log)
+	}
+
+	// A tree change misses the cache.
+	if err := os.WriteFile(filepath.Join(repo, "new.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runSingleAsyncHook(hook, stop, stateDir)
+	if runs, _ := os.ReadFile(counter); strings.Count(string(runs), "run") != 2 {
+		t.Fatal("tree change did not rerun the hook")
+	}
+
+	if n, err := ClearHookCache(); err != nil || n != 2 {
+		t.Fatalf("ClearHookCache = %d, %v", n, err)
+	}
+	runSingleAsyncHook(hook, stop, stateDir)
+	if runs, _ := os.ReadFile(counter); strings.Count(string(runs), "run") != 3 {
+		t.Fatal("cleared cache still replayed")
+	}
+}
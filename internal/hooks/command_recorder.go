@@ -57,12 +57,16 @@ type commandEntry struct {
 	LinkID string `json:"link_id"`
 	// ToolUseID is the real Claude payload id, informational only and present on
 	// post rows (PreToolUse does not provide it).
-	ToolUseID   string   `json:"tool_use_id,omitempty"`
-	Command     string   `json:"command"`
-	Cwd         string   `json:"cwd,omitempty"`
-	Subcommands []string `json:"subcommands,omitempty"`
-	Outcome     string   `json:"outcome"`
-	DurationMs  int64    `json:"duration_ms,omitempty"`
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Command   string `json:"command"`
+	// OriginalCommand is set on pre rows when a [[hooks.bash_rewrite]] rule
+	// changed the command: it holds what the agent asked for, while Command
+	// holds what actually ran.
+	OriginalCommand string   `json:"original_command,omitempty"`
+	Cwd             string   `json:"cwd,omitempty"`
+	Subcommands     []string `json:"subcommands,omitempty"`
+	Outcome         string   `json:"outcome"`
+	DurationMs      int64    `json:"duration_ms,omitempty"`
 }
 
 // extractBashCommand pulls the command string from a tool input that may be
@@ -79,16 +83,13 @@ func extractBashCommand(toolInput any) (string, bool) {
 	return cmd, true
 }
 
-// commandSubcommands splits a compound shell command into its subcommands using
-// the same naive splitter the permission matcher uses (&&, ||, ;, |), dropping
-// empty segments.
+// commandSubcommands lists every program a shell command executes, using the
+// same parser the permission matcher uses — list and pipeline members plus
+// commands nested in substitutions, sh -c scripts and wrappers like sudo.
 func commandSubcommands(cmd string) []string {
 	var subs []string
-	for _, s := range splitShellCommand(cmd) {
-		s = strings.TrimSpace(s)
-		if s != "" {
-			subs = append(subs, s)
-		}
+	for _, c := range parseShellCommands(cmd) {
+		subs = append(subs, c.Text)
 	}
 	return subs
 }
@@ -0,0 +1,245 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grovetools/core/config"
+	"github.com/grovetools/core/logging"
+	"github.com/grovetools/core/pkg/models"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// PreCompact snapshots: before Claude Code compacts a conversation, the tail
+// of the transcript and the plan/job context are copied to
+//
+//	<plan>/.artifacts/<job>/compactions/<timestamp>-<trigger>/
+//
+// (or the per-session hooks state dir when the session has no job), so
+// whatever the summary drops can still be recovered. A snapshot holds
+// transcript_tail.jsonl, context.json and a copy of the job file.
+//
+//	[hooks.pre_compact]
+//	transcript_tail = 200   # transcript lines to keep (default 100)
+//	instructions = "Keep the failing test names and {{.JobTitle}}'s acceptance criteria."
+//	include_file = "{{.JobFilePath}}"
+//
+// instructions and include_file are templates as for post_tool_use reminders
+// (see reminder_template.go); the result is emitted as additionalContext for
+// the compaction.
+
+// defaultTranscriptTail is the number of transcript lines snapshotted when
+// transcript_tail is unset.
+const defaultTranscriptTail = 100
+
+// PreCompactConfig is the [hooks.pre_compact] table.
+type PreCompactConfig struct {
+	TranscriptTail int    `yaml:"transcript_tail"`
+	Instructions   string `yaml:"instructions"`
+	IncludeFile    string `yaml:"include_file"`
+}
+
+// compactionContext is the context.json written into a snapshot.
+type compactionContext struct {
+	Timestamp          time.Time `json:"timestamp"`
+	SessionID          string    `json:"session_id"`
+	Trigger            string    `json:"trigger"`
+	CustomInstructions string    `json:"custom_instructions,omitempty"`
+	TranscriptPath     string    `json:"transcript_path,omitempty"`
+	TranscriptLines    int       `json:"transcript_lines"`
+	Cwd                string    `json:"cwd,omitempty"`
+	PlanName           string    `json:"plan_name,omitempty"`
+	JobTitle           string    `json:"job_title,omitempty"`
+	JobFilePath        string    `json:"job_file_path,omitempty"`
+}
+
+// loadPreCompactConfig reads [hooks.pre_compact] from the grove.toml
+// governing workingDir.
+func loadPreCompactConfig(workingDir string) PreCompactConfig {
+	var hooksConfig struct {
+		PreCompact PreCompactConfig `yaml:"pre_compact"`
+	}
+	if workingDir == "" {
+		return hooksConfig.PreCompact
+	}
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return hooksConfig.PreCompact
+	}
+	_ = cfg.UnmarshalExtension("hooks", &hooksConfig)
+	return hooksConfig.PreCompact
+}
+
+// compactionSnapshotDir picks the directory for a new snapshot: the job's
+// artifacts when the session is bound to a plan, else the session state dir.
+func compactionSnapshotDir(sessionID, trigger string, now time.Time) string {
+	base := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID)
+	if planDir, jobName := resolveFileAccessTarget(sessionID); planDir != "" {
+		base = filepath.Join(planDir, ".artifacts", jobName)
+	}
+	if trigger == "" {
+		trigger = "unknown"
+	}
+	return filepath.Join(base, "compactions", now.UTC().Format("20060102T150405Z")+"-"+trigger)
+}
+
+// writeCompactionSnapshot copies the transcript tail, context and job file
+// into dir.
+func writeCompactionSnapshot(dir string, data PreCompactInput, vars reminderVars, tail int, now time.Time) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	lines := 0
+	if data.TranscriptPath != "" {
+		content, err := readTailLines(data.TranscriptPath, tail)
+		if err != nil {
+			return fmt.Errorf("failed to read transcript: %w", err)
+		}
+		lines = bytes.Count(content, []byte("\n"))
+		if err := os.WriteFile(filepath.Join(dir, "transcript_tail.jsonl"), content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	if vars.JobFilePath != "" {
+		if content, err := os.ReadFile(vars.JobFilePath); err == nil {
+			if err := os.WriteFile(filepath.Join(dir, filepath.Base(vars.JobFilePath)), content, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+
+	meta, err := json.MarshalIndent(compactionContext{
+		Timestamp:          now,
+		SessionID:          data.SessionID,
+		Trigger:            data.Trigger,
+		CustomInstructions: data.CustomInstructions,
+		TranscriptPath:     data.TranscriptPath,
+		TranscriptLines:    lines,
+		Cwd:                vars.Cwd,
+		PlanName:           vars.PlanName,
+		JobTitle:           vars.JobTitle,
+		JobFilePath:        vars.JobFilePath,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "context.json"), meta, 0o644)
+}
+
+// readTailLines returns the last n lines of a file, reading backwards from
+// the end so large transcripts are not loaded whole. The result always ends
+// in a newline when non-empty.
+func readTailLines(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const chunk = 64 * 1024
+	var buf []byte
+	offset := info.Size()
+	for offset > 0 {
+		size := int64(chunk)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		block := make([]byte, size)
+		if _, err := f.ReadAt(block, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(block, buf...)
+		// n newlines past the first (possibly partial) line means the last n
+		// lines are complete.
+		if bytes.Count(bytes.TrimRight(buf, "\n"), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	buf = bytes.TrimRight(buf, "\n")
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	lines := bytes.Split(buf, []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return append(bytes.Join(lines, []byte("\n")), '\n'), nil
+}
+
+// RunPreCompactHook handles the PreCompact hook: it snapshots the session
+// before compaction and emits any configured compaction instructions.
+func RunPreCompactHook() {
+	slog := logging.NewLogger("hooks.pre-compact")
+
+	ctx, err := NewHookContext()
+	if err != nil {
+		slog.WithError(err).Error("Error initializing hook context")
+		os.Exit(1)
+	}
+
+	var data PreCompactInput
+	if err := json.Unmarshal(ctx.RawInput, &data); err != nil {
+		slog.WithError(err).Error("Error parsing JSON")
+		os.Exit(1)
+	}
+
+	workingDir := data.Cwd
+	if workingDir == "" {
+		workingDir = os.Getenv("PWD")
+	}
+	cfg := loadPreCompactConfig(workingDir)
+	vars := newReminderVars(data.SessionID, "", nil, workingDir)
+	now := time.Now()
+
+	tail := cfg.TranscriptTail
+	if tail <= 0 {
+		tail = defaultTranscriptTail
+	}
+	snapshotDir := ""
+	if data.SessionID != "" {
+		snapshotDir = compactionSnapshotDir(data.SessionID, data.Trigger, now)
+		if err := writeCompactionSnapshot(snapshotDir, data, vars, tail, now); err != nil {
+			slog.WithFields(logrus.Fields{
+				"session_id":   data.SessionID,
+				"snapshot_dir": snapshotDir,
+				"error":        err.Error(),
+			}).Warn("Failed to write compaction snapshot")
+		}
+	}
+
+	if err := ctx.LogEvent(models.EventType("pre_compact"), map[string]any{
+		"trigger":      data.Trigger,
+		"snapshot_dir": snapshotDir,
+	}); err != nil {
+		slog.WithError(err).Debug("Failed to log pre_compact event")
+	}
+
+	instructions := renderContext("pre_compact", cfg.Instructions, cfg.IncludeFile, vars)
+	if instructions == "" {
+		return
+	}
+	payload, err := json.Marshal(PreCompactResponse{
+		HookSpecificOutput: PreCompactHookOutput{
+			HookEventName:     "PreCompact",
+			AdditionalContext: instructions,
+		},
+	})
+	if err != nil {
+		return
+	}
+	fmt.Print(string(payload))
+}
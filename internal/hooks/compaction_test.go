@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+func TestReadTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	var b strings.Builder
+	for i := 1; i <= 5000; i++ {
+		fmt.Fprintf(&b, `{"n":%d,"pad":"%s"}`+"\n", i, strings.Repeat("x", 40))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readTailLines(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(got), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"n":4998,`) || !strings.HasPrefix(lines[2], `{"n":5000,`) {
+		t.Fatalf("tail = %q", got)
+	}
+
+	// More lines than the file has returns the whole file.
+	got, err = readTailLines(path, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != b.String() {
+		t.Fatalf("full tail mismatch: %d bytes, want %d", len(got), b.Len())
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.jsonl")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readTailLines(empty, 3); err != nil || len(got) != 0 {
+		t.Fatalf("empty tail = %q, %v", got, err)
+	}
+}
+
+func TestWriteCompactionSnapshot(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	work := t.TempDir()
+	transcript := filepath.Join(work, "t.jsonl")
+	if err := os.WriteFile(transcript, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobFile := filepath.Join(work, "01-job.md")
+	if err := os.WriteFile(jobFile, []byte("# Job\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	data := PreCompactInput{SessionID: "sess-c", TranscriptPath: transcript, Trigger: "auto"}
+	vars := reminderVars{Cwd: work, PlanName: "p", JobTitle: "Job", JobFilePath: jobFile}
+
+	// No job binding resolves: falls back to the session state dir.
+	dir := compactionSnapshotDir(data.SessionID, data.Trigger, now)
+	if want := filepath.Join(paths.StateDir(), "hooks", "sessions", "sess-c", "compactions", "20260506T070809Z-auto"); dir != want {
+		t.Fatalf("snapshot dir = %q, want %q", dir, want)
+	}
+	if err := writeCompactionSnapshot(dir, data, vars, 2, now); err != nil {
+		t.Fatal(err)
+	}
+
+	tail, err := os.ReadFile(filepath.Join(dir, "transcript_tail.jsonl"))
+	if err != nil || string(tail) != "b\nc\n" {
+		t.Fatalf("transcript tail = %q, %v", tail, err)
+	}
+	if job, err := os.ReadFile(filepath.Join(dir, "01-job.md")); err != nil || string(job) != "# Job\n" {
+		t.Fatalf("job copy = %q, %v", job, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "context.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ctx compactionContext
+	if err := json.Unmarshal(content, &ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.SessionID != "sess-c" || ctx.Trigger != "auto" || ctx.TranscriptLines != 2 || ctx.JobFilePath != jobFile || ctx.PlanName != "p" {
+		t.Fatalf("context = %+v", ctx)
+	}
+}
@@ -1,16 +1,114 @@
 package hooks
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/grovetools/core/pkg/paths"
 )
 
-// repoSlug derives a marker-dir-safe slug from the repo working directory's
-// basename. Uses the same slugification rules as hook names so the layout is
-// consistent.
-func repoSlug(workingDir string) string {
+// Disable markers: `grove hooks disable` creates a marker file per hook, in
+// one of three scopes:
+//
+//	repo      StateDir()/hooks/markers/<repo>/<hook>                      every worktree of the repo
+//	worktree  StateDir()/hooks/markers/<repo>/worktrees/<worktree>/<hook> this checkout only
+//	session   StateDir()/hooks/sessions/<session_id>/disabled/<hook>      one session only
+//
+// <repo> is the main checkout's name plus a hash of the git common dir, so
+// same-named checkouts elsewhere do not share state while all worktrees of
+// one repo do; <worktree> hashes the worktree's top-level path. Outside git
+// both are derived from the working directory. The narrowest marker wins.
+//
+// Markers used to live in StateDir()/hooks/disabled/<basename>; they move to
+// the repo scope of the first checkout with that basename that looks them up.
+//
+// A marker holds JSON with the reason, when it was created and, for a
+// temporary disable (`disable --for 2h` or `--until <time>`), when it
+// expires. An expired marker no longer disables anything and is removed when
+// next looked up. Session markers also go away with the session's state dir.
+// Older markers hold only the reason as plain text and never expire.
+
+// DisableScope is where a disable marker applies.
+type DisableScope string
+
+const (
+	DisableRepo     DisableScope = "repo"
+	DisableWorktree DisableScope = "worktree"
+	DisableSession  DisableScope = "session"
+)
+
+// ParseDisableScope validates a scope name.
+func ParseDisableScope(s string) (DisableScope, error) {
+	switch scope := DisableScope(s); scope {
+	case DisableRepo, DisableWorktree, DisableSession:
+		return scope, nil
+	}
+	return "", fmt.Errorf("invalid scope %q: want repo, worktree or session", s)
+}
+
+// repoIdentity identifies the repository and worktree of a working dir.
+type repoIdentity struct {
+	Repo     string
+	Worktree string
+	// Top is the worktree's top-level directory, or the working dir outside
+	// git.
+	Top string
+}
+
+var repoIdentities sync.Map // working dir -> repoIdentity
+
+// resolveRepoIdentity derives the marker keys for workingDir; results are
+// cached for the life of the process.
+func resolveRepoIdentity(workingDir string) repoIdentity {
+	abs, err := filepath.Abs(workingDir)
+	if err != nil {
+		abs = workingDir
+	}
+	if id, ok := repoIdentities.Load(abs); ok {
+		return id.(repoIdentity)
+	}
+
+	commonDir, top := abs, abs
+	name := filepath.Base(abs)
+	if out := gitOutput(abs, "rev-parse", "--path-format=absolute", "--git-common-dir", "--show-toplevel"); out != "" {
+		if lines := strings.Split(out, "\n"); len(lines) == 2 {
+			commonDir, top = lines[0], lines[1]
+			// <repo>/.git for a normal checkout, <repo>.git when bare.
+			name = strings.TrimSuffix(filepath.Base(commonDir), ".git")
+			if name == "" {
+				name = filepath.Base(filepath.Dir(commonDir))
+			}
+		}
+	}
+	id := repoIdentity{
+		Repo:     slugifyHookName(name) + "-" + shortPathHash(commonDir),
+		Worktree: shortPathHash(top),
+		Top:      top,
+	}
+	repoIdentities.Store(abs, id)
+	return id
+}
+
+// shortPathHash hashes a path after resolving symlinks.
+func shortPathHash(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:6])
+}
+
+// legacyRepoSlug is the basename slug markers, flakiness and history were
+// keyed on before repo identities.
+func legacyRepoSlug(workingDir string) string {
 	abs, err := filepath.Abs(workingDir)
 	if err != nil {
 		abs = workingDir
@@ -18,59 +116,220 @@ func repoSlug(workingDir string) string {
 	return slugifyHookName(filepath.Base(abs))
 }
 
-// HookMarkerDir returns the directory holding disable-marker files for the
-// given repo working directory.
+// migrateLegacyPath moves a file or directory keyed on the legacy slug to
+// its new path, unless something already lives there.
+func migrateLegacyPath(legacy, current string) {
+	if _, err := os.Lstat(legacy); err != nil {
+		return
+	}
+	if _, err := os.Lstat(current); err == nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(current), 0o755); err != nil {
+		return
+	}
+	_ = os.Rename(legacy, current)
+}
+
+// repoStateFile returns StateDir()/hooks/<kind>/<repo><ext>, the per-repo
+// file of kind for workingDir, first moving a file keyed on the legacy
+// basename slug there.
+func repoStateFile(kind, workingDir, ext string) string {
+	dir := filepath.Join(paths.StateDir(), "hooks", kind)
+	path := filepath.Join(dir, resolveRepoIdentity(workingDir).Repo+ext)
+	migrateLegacyPath(filepath.Join(dir, legacyRepoSlug(workingDir)+ext), path)
+	return path
+}
+
+var migratedMarkers sync.Map // working dir -> struct{}
+
+// migrateLegacyMarkers moves markers from the basename layout into the repo
+// scope of workingDir, once per process. Markers already in the repo scope
+// are kept.
+func migrateLegacyMarkers(workingDir string) {
+	if _, done := migratedMarkers.LoadOrStore(workingDir, struct{}{}); done {
+		return
+	}
+	legacyDir := filepath.Join(paths.StateDir(), "hooks", "disabled", legacyRepoSlug(workingDir))
+	entries, err := os.ReadDir(legacyDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			migrateLegacyPath(filepath.Join(legacyDir, entry.Name()), filepath.Join(HookMarkerDir(workingDir), entry.Name()))
+		}
+	}
+	_ = os.Remove(legacyDir) // only once empty
+}
+
+// HookMarkerDir returns the directory holding repo-scope disable markers for
+// the given repo working directory.
 func HookMarkerDir(workingDir string) string {
-	return filepath.Join(paths.StateDir(), "hooks", "disabled", repoSlug(workingDir))
+	return filepath.Join(paths.StateDir(), "hooks", "markers", resolveRepoIdentity(workingDir).Repo)
 }
 
-// HookMarkerPath returns the marker-file path for a single hook.
+// HookMarkerPath returns the repo-scope marker-file path for a single hook.
 func HookMarkerPath(workingDir, hookName string) string {
-	return filepath.Join(HookMarkerDir(workingDir), slugifyHookName(hookName))
+	return HookMarkerPathInScope(workingDir, "", hookName, DisableRepo)
 }
 
-// IsHookDisabledByMarker reports whether the marker file for the named hook
-// exists. Any stat error other than not-exist is treated as "not disabled" —
-// we never want a transient FS error to silently skip a hook.
-func IsHookDisabledByMarker(workingDir, hookName string) bool {
+// HookMarkerPathInScope returns the marker-file path for a hook in scope.
+// sessionID (a provider or grove session id) is used only by the session
+// scope.
+func HookMarkerPathInScope(workingDir, sessionID, hookName string, scope DisableScope) string {
+	slug := slugifyHookName(hookName)
+	switch scope {
+	case DisableWorktree:
+		return filepath.Join(HookMarkerDir(workingDir), "worktrees", resolveRepoIdentity(workingDir).Worktree, slug)
+	case DisableSession:
+		nativeID, _ := resolveNativeSessionID(sessionID)
+		return filepath.Join(paths.StateDir(), "hooks", "sessions", nativeID, "disabled", slug)
+	default:
+		return filepath.Join(HookMarkerDir(workingDir), slug)
+	}
+}
+
+// HookMarker is a disable marker in effect for a hook.
+type HookMarker struct {
+	Scope      DisableScope
+	Path       string
+	Reason     string
+	DisabledAt time.Time
+	ExpiresAt  time.Time
+}
+
+// hookMarkerFile is the JSON content of a marker file.
+type hookMarkerFile struct {
+	Reason     string    `json:"reason,omitempty"`
+	DisabledAt time.Time `json:"disabled_at"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero"`
+}
+
+// Expired reports whether a temporary disable has run out at now.
+func (m HookMarker) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// readHookMarker parses the marker file at path. Plain-text content is a
+// reason from before markers held JSON.
+func readHookMarker(path string) (HookMarker, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return HookMarker{}, err
+	}
+	marker := HookMarker{Path: path}
+	var file hookMarkerFile
+	if bytes.HasPrefix(content, []byte("{")) && json.Unmarshal(content, &file) == nil {
+		marker.Reason = file.Reason
+		marker.DisabledAt = file.DisabledAt
+		marker.ExpiresAt = file.ExpiresAt
+		return marker, nil
+	}
+	marker.Reason = strings.TrimRight(string(content), "\r\n")
+	return marker, nil
+}
+
+// FindHookMarker returns the narrowest marker disabling the named hook for
+// workingDir and, when sessionID is set, that session. Any stat error other
+// than not-exist is treated as "not disabled" — we never want a transient FS
+// error to silently skip a hook.
+func FindHookMarker(workingDir, sessionID, hookName string) (HookMarker, bool) {
 	if workingDir == "" || hookName == "" {
-		return false
+		return HookMarker{}, false
+	}
+	migrateLegacyMarkers(workingDir)
+	scopes := []DisableScope{DisableWorktree, DisableRepo}
+	if sessionID != "" {
+		scopes = append([]DisableScope{DisableSession}, scopes...)
+	}
+	now := time.Now()
+	for _, scope := range scopes {
+		path := HookMarkerPathInScope(workingDir, sessionID, hookName, scope)
+		marker, err := readHookMarker(path)
+		if err != nil {
+			continue
+		}
+		if marker.Expired(now) {
+			_ = os.Remove(path)
+			continue
+		}
+		marker.Scope = scope
+		return marker, true
 	}
-	_, err := os.Stat(HookMarkerPath(workingDir, hookName))
-	return err == nil
+	return HookMarker{}, false
 }
 
-// DisableHook creates the marker file for the named hook. Reason may be empty.
+// IsHookDisabledByMarker reports whether an unexpired repo or worktree
+// marker disables the named hook.
+func IsHookDisabledByMarker(workingDir, hookName string) bool {
+	_, ok := FindHookMarker(workingDir, "", hookName)
+	return ok
+}
+
+// DisableHook creates the repo-scope marker file for the named hook. Reason
+// may be empty.
 func DisableHook(workingDir, hookName, reason string) error {
-	dir := HookMarkerDir(workingDir)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	return DisableHookInScope(workingDir, "", hookName, reason, DisableRepo, time.Time{})
+}
+
+// DisableHookInScope creates the marker file for the named hook in scope,
+// expiring at expiresAt unless it is zero. A session-scope disable needs a
+// known session.
+func DisableHookInScope(workingDir, sessionID, hookName, reason string, scope DisableScope, expiresAt time.Time) error {
+	if scope == DisableSession {
+		if sessionID == "" {
+			return fmt.Errorf("a session-scope disable needs a session id")
+		}
+		if _, err := ResolveSessionID(sessionID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return fmt.Errorf("expiry %s is not in the future", expiresAt.Format(time.RFC3339))
+	}
+	migrateLegacyMarkers(workingDir)
+	path := HookMarkerPathInScope(workingDir, sessionID, hookName, scope)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	content, err := json.Marshal(hookMarkerFile{Reason: reason, DisabledAt: now.UTC(), ExpiresAt: expiresAt.UTC()})
+	if err != nil {
 		return err
 	}
-	path := HookMarkerPath(workingDir, hookName)
-	return os.WriteFile(path, []byte(reason), 0o644)
+	return os.WriteFile(path, content, 0o644)
 }
 
-// EnableHook removes the marker file for the named hook. Idempotent.
+// EnableHook removes the repo and worktree marker files for the named hook.
+// Idempotent.
 func EnableHook(workingDir, hookName string) error {
-	path := HookMarkerPath(workingDir, hookName)
-	err := os.Remove(path)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	for _, scope := range []DisableScope{DisableRepo, DisableWorktree} {
+		if err := EnableHookInScope(workingDir, "", hookName, scope); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// HookDisableReason returns the contents of the marker file (the reason),
-// trimmed of trailing newlines. Returns empty string if not disabled or if
-// the file is empty.
-func HookDisableReason(workingDir, hookName string) string {
-	b, err := os.ReadFile(HookMarkerPath(workingDir, hookName))
-	if err != nil {
-		return ""
+// EnableHookInScope removes the named hook's marker file in scope.
+// Idempotent.
+func EnableHookInScope(workingDir, sessionID, hookName string, scope DisableScope) error {
+	if scope == DisableSession && sessionID == "" {
+		return nil
 	}
-	out := string(b)
-	for len(out) > 0 && (out[len(out)-1] == '\n' || out[len(out)-1] == '\r') {
-		out = out[:len(out)-1]
+	migrateLegacyMarkers(workingDir)
+	err := os.Remove(HookMarkerPathInScope(workingDir, sessionID, hookName, scope))
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
-	return out
+	return nil
+}
+
+// HookDisableReason returns the reason recorded in the marker disabling the
+// named hook for workingDir and, when sessionID is set, that session.
+// Returns empty string if not disabled or if no reason was given.
+func HookDisableReason(workingDir, sessionID, hookName string) string {
+	marker, _ := FindHookMarker(workingDir, sessionID, hookName)
+	return marker.Reason
 }
@@ -0,0 +1,184 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+func TestDisableMarkersKeyedOnRepoIdentity(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	// Two unrelated checkouts that share a basename.
+	api1 := filepath.Join(initGitRepo(t), "api")
+	api2 := filepath.Join(initGitRepo(t), "api")
+	for _, dir := range []string{api1, api2} {
+		if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+			t.Skipf("git init: %v: %s", err, out)
+		}
+	}
+	if err := DisableHook(api1, "lint", "broken"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsHookDisabledByMarker(api1, "lint") || HookDisableReason(api1, "", "lint") != "broken" {
+		t.Error("lint should be disabled in api1 with its reason")
+	}
+	if IsHookDisabledByMarker(api2, "lint") {
+		t.Error("a marker in api1 must not disable lint in an unrelated checkout named api")
+	}
+}
+
+func TestDisableMarkerScopes(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := initGitRepo(t)
+	worktree := filepath.Join(t.TempDir(), "feature")
+	cmd := exec.Command("git", "worktree", "add", "-q", "-b", "feature", worktree)
+	cmd.Dir = repo
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("git worktree add: %v: %s", err, out)
+	}
+
+	// Repo scope reaches every worktree.
+	if err := DisableHook(repo, "lint", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !IsHookDisabledByMarker(worktree, "lint") {
+		t.Error("repo-scope marker should apply to the linked worktree")
+	}
+
+	// Worktree scope stays in its checkout and wins over the repo marker.
+	if err := DisableHookInScope(worktree, "", "test", "", DisableWorktree, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsHookDisabledByMarker(worktree, "test") || IsHookDisabledByMarker(repo, "test") {
+		t.Error("worktree-scope marker should apply only to its worktree")
+	}
+	if err := DisableHookInScope(worktree, "", "lint", "wip", DisableWorktree, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if marker, _ := FindHookMarker(worktree, "", "lint"); marker.Scope != DisableWorktree || marker.Reason != "wip" {
+		t.Errorf("narrowest marker = %+v, want the worktree one", marker)
+	}
+
+	// Session scope applies only when that session is asked about.
+	if err := DisableHookInScope(repo, "", "e2e", "", DisableSession, time.Time{}); err == nil {
+		t.Error("session scope without a session id should fail")
+	}
+	if err := DisableHookInScope(repo, "native-9", "e2e", "", DisableSession, time.Time{}); err == nil {
+		t.Error("session scope with an unknown session should fail")
+	}
+	if _, err := os.Stat(filepath.Join(paths.StateDir(), "hooks", "sessions", "native-9")); err == nil {
+		t.Error("unknown session got a state dir")
+	}
+	registerTestSession(t, "native-1")
+	if err := DisableHookInScope(repo, "native-1", "e2e", "", DisableSession, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindHookMarker(repo, "native-1", "e2e"); !ok {
+		t.Error("session marker should disable e2e for native-1")
+	}
+	if _, ok := FindHookMarker(repo, "native-2", "e2e"); ok || IsHookDisabledByMarker(repo, "e2e") {
+		t.Error("session marker must not apply outside its session")
+	}
+	stop := &onStopContext{WorkingDir: worktree, NativeSessionID: "native-1"}
+	if got := stop.gateReason(onStopHook("e2e", "true")); got != "disabled (session)" {
+		t.Errorf("gate reason = %q, want disabled (session)", got)
+	}
+
+	// enable clears the repo and worktree markers.
+	if err := EnableHook(worktree, "lint"); err != nil {
+		t.Fatal(err)
+	}
+	if IsHookDisabledByMarker(worktree, "lint") || IsHookDisabledByMarker(repo, "lint") {
+		t.Error("lint should be enabled everywhere after EnableHook")
+	}
+}
+
+func TestLegacyMarkersMigrate(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := initGitRepo(t)
+	legacyDir := filepath.Join(paths.StateDir(), "hooks", "disabled", legacyRepoSlug(repo))
+	if err := os.MkdirAll(legacyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacyDir, "lint"), []byte("old reason\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	legacyFlakiness := filepath.Join(paths.StateDir(), "hooks", "flakiness", legacyRepoSlug(repo)+".json")
+	if err := os.MkdirAll(filepath.Dir(legacyFlakiness), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyFlakiness, []byte(`{"lint":{"runs":3}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if HookDisableReason(repo, "", "lint") != "old reason" {
+		t.Error("legacy marker should still disable lint after migration")
+	}
+	if _, err := os.Stat(HookMarkerPath(repo, "lint")); err != nil {
+		t.Errorf("marker not moved to the repo scope: %v", err)
+	}
+	if _, err := os.Stat(legacyDir); !os.IsNotExist(err) {
+		t.Error("emptied legacy marker dir should be removed")
+	}
+	if h, ok := HookFlakinessFor(repo, "lint"); !ok || h.Runs != 3 {
+		t.Errorf("flakiness not migrated: %+v", h)
+	}
+}
+
+func TestTemporaryDisableExpires(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := DisableHookInScope(work, "", "lint", "flaky", DisableRepo, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	marker, ok := FindHookMarker(work, "", "lint")
+	if !ok || marker.Reason != "flaky" || !marker.ExpiresAt.Equal(expiresAt.UTC()) || marker.DisabledAt.IsZero() {
+		t.Fatalf("marker = %+v, %v", marker, ok)
+	}
+	if err := DisableHookInScope(work, "", "lint", "", DisableRepo, time.Now().Add(-time.Minute)); err == nil {
+		t.Error("an expiry in the past should be rejected")
+	}
+
+	// Backdate the marker past its expiry.
+	content, _ := json.Marshal(hookMarkerFile{Reason: "flaky", DisabledAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Second)})
+	if err := os.WriteFile(HookMarkerPath(work, "lint"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if IsHookDisabledByMarker(work, "lint") {
+		t.Error("an expired marker must not disable the hook")
+	}
+	if _, err := os.Stat(HookMarkerPath(work, "lint")); !os.IsNotExist(err) {
+		t.Error("expired marker should be removed")
+	}
+
+	// Plain-text markers from older versions never expire.
+	if err := os.WriteFile(HookMarkerPath(work, "test"), []byte("legacy reason\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if marker, ok := FindHookMarker(work, "", "test"); !ok || marker.Reason != "legacy reason" || !marker.ExpiresAt.IsZero() {
+		t.Errorf("plain-text marker = %+v, %v", marker, ok)
+	}
+}
+
+// registerTestSession creates the state dir of a session, as registration
+// does.
+func registerTestSession(t *testing.T, sessionID string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,271 @@
+package hooks
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Glob matching for permission rules. filepath.Match cannot express the
+// patterns Claude Code's permission syntax uses, so rules are matched with
+// this small doublestar-style matcher instead:
+//
+//	*        any run of characters; in path mode it stops at '/'
+//	**       any run of characters including '/'; "**/" also matches zero
+//	         directories, so "src/**/*.go" matches "src/main.go"
+//	?        one character (not '/' in path mode)
+//	[abc]    character class; ranges ([a-z]) and negation ([!x] or [^x])
+//	{a,b}    alternatives, which may nest and contain other wildcards
+//	\x       the literal character x
+//
+// An unterminated '[' or '{' is matched literally rather than rejected, so a
+// stray bracket in a Bash command rule never turns the rule into an error.
+
+// maxBraceExpansions caps how many alternatives one pattern may expand to, so
+// a pathological rule cannot make every tool call expensive.
+const maxBraceExpansions = 256
+
+// matchGlob reports whether name matches pattern. pathMode makes '*' and '?'
+// stop at '/' (file rules); otherwise every wildcard crosses '/' (Bash
+// command rules, where arguments routinely contain paths).
+func matchGlob(pattern, name string, pathMode bool) bool {
+	for _, alt := range expandBraces(pattern) {
+		if matchExpanded(alt, name, pathMode) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExpanded matches a brace-free pattern.
+func matchExpanded(pattern, name string, pathMode bool) bool {
+	m := globMatcher{
+		pattern:  pattern,
+		name:     name,
+		pathMode: pathMode,
+		failed:   make([]bool, (len(pattern)+1)*(len(name)+1)),
+	}
+	return m.match(0, 0)
+}
+
+// globMatcher matches one brace-free pattern against one name by
+// backtracking over its wildcards. Every (pattern, name) position a wildcard
+// failed to match from is remembered and never retried, so matching takes
+// O(len(pattern)·len(name)) steps however many wildcards the pattern has.
+type globMatcher struct {
+	pattern  string
+	name     string
+	pathMode bool
+	failed   []bool // indexed by pi*(len(name)+1) + ni
+}
+
+// match reports whether pattern[pi:] matches name[ni:].
+func (m *globMatcher) match(pi, ni int) bool {
+	pattern, name := m.pattern, m.name
+	for pi < len(pattern) {
+		switch pattern[pi] {
+		case '*':
+			key := pi*(len(name)+1) + ni
+			if m.failed[key] {
+				return false
+			}
+			if m.matchStar(pi, ni) {
+				return true
+			}
+			m.failed[key] = true
+			return false
+		case '?':
+			if ni == len(name) || (m.pathMode && name[ni] == '/') {
+				return false
+			}
+			_, size := utf8.DecodeRuneInString(name[ni:])
+			pi, ni = pi+1, ni+size
+		case '[':
+			r, size := utf8.DecodeRuneInString(name[ni:])
+			matched, width, ok := matchClass(pattern[pi:], r)
+			if !ok {
+				// Unterminated class: treat '[' literally.
+				if !strings.HasPrefix(name[ni:], "[") {
+					return false
+				}
+				pi, ni = pi+1, ni+1
+				continue
+			}
+			if ni == len(name) || !matched || (m.pathMode && r == '/') {
+				return false
+			}
+			pi, ni = pi+width, ni+size
+		case '\\':
+			if pi+1 < len(pattern) {
+				pi++
+			}
+			fallthrough
+		default:
+			pr, psize := utf8.DecodeRuneInString(pattern[pi:])
+			nr, nsize := utf8.DecodeRuneInString(name[ni:])
+			if ni == len(name) || pr != nr {
+				return false
+			}
+			pi, ni = pi+psize, ni+nsize
+		}
+	}
+	return ni == len(name)
+}
+
+// matchStar matches the '*' or '**' at pattern[pi] and the rest of the
+// pattern against name[ni:]: either the wildcard ends here, or it consumes
+// one more character (one more directory for "**/") and is tried again.
+func (m *globMatcher) matchStar(pi, ni int) bool {
+	pattern, name := m.pattern, m.name
+	if !strings.HasPrefix(pattern[pi:], "**") {
+		if m.match(pi+1, ni) {
+			return true
+		}
+		return ni < len(name) && !(m.pathMode && name[ni] == '/') && m.match(pi, ni+1)
+	}
+	rest := len(pattern) - len(strings.TrimLeft(pattern[pi:], "*"))
+	if m.pathMode && strings.HasPrefix(pattern[rest:], "/") {
+		// "**/" matches zero or more whole directories.
+		if m.match(rest+1, ni) {
+			return true
+		}
+		slash := strings.IndexByte(name[ni:], '/')
+		return slash >= 0 && m.match(pi, ni+slash+1)
+	}
+	if m.match(rest, ni) {
+		return true
+	}
+	return ni < len(name) && m.match(pi, ni+1)
+}
+
+// matchClass matches r against the character class at the start of pattern.
+// width is the byte length of the class including its brackets; ok=false
+// means the class is unterminated.
+func matchClass(pattern string, r rune) (matched bool, width int, ok bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+		lo, size := classRune(pattern, i)
+		if size == 0 {
+			return false, 0, false
+		}
+		i += size
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = classRune(pattern, i+1)
+			if size == 0 {
+				return false, 0, false
+			}
+			i += 1 + size
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
+
+// classRune decodes one possibly-escaped rune of a character class at i,
+// returning its byte size (0 when the pattern ends mid-escape).
+func classRune(pattern string, i int) (rune, int) {
+	if pattern[i] == '\\' {
+		if i+1 >= len(pattern) {
+			return 0, 0
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i+1:])
+		return r, size + 1
+	}
+	r, size := utf8.DecodeRuneInString(pattern[i:])
+	return r, size
+}
+
+// expandBraces expands {a,b} alternatives into separate patterns, innermost
+// groups included. A '{' without a matching '}' or without a top-level comma
+// is kept literally.
+func expandBraces(pattern string) []string {
+	open, closing, ok := findBraceGroup(pattern)
+	if !ok {
+		return []string{pattern}
+	}
+	prefix, suffix := pattern[:open], pattern[closing+1:]
+	var out []string
+	for _, alt := range splitTopLevel(pattern[open+1:closing], ',') {
+		for _, expanded := range expandBraces(prefix + alt + suffix) {
+			out = append(out, expanded)
+			if len(out) >= maxBraceExpansions {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// findBraceGroup locates the first unescaped {...} group that contains a
+// top-level comma.
+func findBraceGroup(pattern string) (open, closing int, ok bool) {
+	for start := 0; start < len(pattern); start++ {
+		switch pattern[start] {
+		case '\\':
+			start++
+			continue
+		case '{':
+		default:
+			continue
+		}
+		depth, comma := 0, false
+		for i := start; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '\\':
+				i++
+			case '{':
+				depth++
+			case ',':
+				if depth == 1 {
+					comma = true
+				}
+			case '}':
+				depth--
+				if depth == 0 {
+					if comma {
+						return start, i, true
+					}
+					i = len(pattern)
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitTopLevel splits s on sep wherever it is not nested inside (), {} or
+// [], and not escaped.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, last := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, s[last:])
+}
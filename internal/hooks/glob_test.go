@@ -0,0 +1,104 @@
+package hooks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		pathMode bool
+		want     bool
+	}{
+		// Single star stops at '/' only in path mode.
+		{"*.go", "main.go", true, true},
+		{"*.go", "cmd/main.go", true, false},
+		{"rm -rf *", "rm -rf /tmp/x", false, true},
+		{"src/*", "src/a/b.go", true, false},
+
+		// Double star crosses directories; "**/" also matches zero of them.
+		{"src/**/*.go", "src/main.go", true, true},
+		{"src/**/*.go", "src/a/b/c.go", true, true},
+		{"src/**/*.go", "srcx/a.go", true, false},
+		{"src/**", "src/a/b", true, true},
+		{"**/testdata/**", "pkg/testdata/x.json", true, true},
+		{"**", "a/b/c", true, true},
+
+		// Question marks and classes.
+		{"file?.txt", "file1.txt", true, true},
+		{"file?.txt", "file/.txt", true, false},
+		{"[a-c]*.go", "b.go", true, true},
+		{"[!a-c]*.go", "b.go", true, false},
+		{"[^a-c]*.go", "d.go", true, true},
+		{"[]]", "]", false, true},
+		{"[abc", "[abc", false, true},
+
+		// Braces, including nesting and wildcards inside alternatives.
+		{"{*.yaml,*.yml}", "ci.yml", true, true},
+		{"{*.yaml,*.yml}", "ci.json", true, false},
+		{"*.{go,{ts,tsx}}", "app.tsx", true, true},
+		{"{src,lib}/**/*.go", "lib/x/y.go", true, true},
+		{"{solo}", "{solo}", false, true},
+		{"a{b", "a{b", false, true},
+
+		// Escapes.
+		{`\*.go`, "*.go", true, true},
+		{`\*.go`, "x.go", true, false},
+		{`[\]]`, "]", false, true},
+
+		// Multibyte characters are single runes.
+		{"h?llo", "héllo", false, true},
+		{"", "", false, true},
+		{"", "x", false, false},
+	}
+	for _, tc := range tests {
+		if got := matchGlob(tc.pattern, tc.name, tc.pathMode); got != tc.want {
+			t.Errorf("matchGlob(%q, %q, path=%v) = %v, want %v", tc.pattern, tc.name, tc.pathMode, got, tc.want)
+		}
+	}
+}
+
+func TestMatchGlobManyStarsStaysFast(t *testing.T) {
+	// Naive backtracking tries every split of the name between the stars.
+	pattern := strings.Repeat("*a", 12) + "b"
+	name := strings.Repeat("a", 4096)
+	for _, pathMode := range []bool{false, true} {
+		start := time.Now()
+		if matchGlob(pattern, name, pathMode) {
+			t.Errorf("matchGlob(%q, a×4096, path=%v) = true, want false", pattern, pathMode)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("matchGlob took %v on a non-matching name", elapsed)
+		}
+	}
+	if !matchGlob("**/"+strings.Repeat("*a", 8)+"/**", strings.Repeat("a/", 50)+strings.Repeat("a", 8)+"/x", true) {
+		t.Error("expected many-star pattern to match a deep path")
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"plain", []string{"plain"}},
+		{"{a,b}", []string{"a", "b"}},
+		{"x{a,b}y{1,2}", []string{"xay1", "xay2", "xby1", "xby2"}},
+		{"{a,{b,c}}", []string{"a", "b", "c"}},
+		{`\{a,b}`, []string{`\{a,b}`}},
+	}
+	for _, tc := range tests {
+		if got := expandBraces(tc.pattern); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expandBraces(%q) = %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+
+	huge := "{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}"
+	if got := len(expandBraces(huge)); got != maxBraceExpansions {
+		t.Errorf("expansion count = %d, want cap %d", got, maxBraceExpansions)
+	}
+}
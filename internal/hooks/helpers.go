@@ -129,12 +129,42 @@ func sendSystemNotification(data NotificationInput) bool {
 	return err == nil
 }
 
-func validateTool(toolName string, toolInput map[string]any, workingDir string) PreToolUseResponse {
-	// Tool validation logic here
-	// For now, approve all tools
-	return PreToolUseResponse{
-		Approved: true,
+// validateTool applies the repo's PreToolUse config to a tool call. Bash
+// rewrites run first so that static rules, then validator commands (skipped
+// once a static rule has already denied), judge the command that will
+// actually run. payload is the raw PreToolUse JSON handed to validators on
+// stdin. With no decision and no rewrite the tool is approved and no
+// permission decision is emitted; a deny discards any rewrite. A rewrite's own
+// decision applies only when no rule or validator judged the rewritten
+// command; an explicit allow for that command approves it without a prompt.
+func validateTool(toolName string, toolInput map[string]any, payload []byte, workingDir string) PreToolUseResponse {
+	cfg := loadPreToolUseConfig(workingDir)
+	effectiveInput, rewrite, rewritten := applyBashRewrites(cfg.BashRewrite, toolName, toolInput, workingDir)
+	if rewritten {
+		payload = withToolInput(payload, effectiveInput)
+	}
+
+	decision, ok := evaluatePreToolUsePolicy(cfg.Rules, toolName, effectiveInput, workingDir)
+	if !ok || decision.Action != permissionDeny {
+		if verdict, vok := runPreToolUseValidators(cfg.Rules, toolName, effectiveInput, payload, workingDir); vok {
+			if !ok || outranks(verdict.Action, decision.Action) {
+				decision, ok = verdict, true
+			}
+		}
+	}
+	if rewritten && !ok {
+		decision, ok = rewrite, true
+	}
+	if !ok {
+		return PreToolUseResponse{
+			Approved: true,
+		}
+	}
+	response := newPreToolUseResponse(decision)
+	if rewritten && decision.Action != permissionDeny {
+		response.HookSpecificOutput.UpdatedInput = effectiveInput
 	}
+	return response
 }
 
 func storeToolID(sessionID, toolID string) {
@@ -0,0 +1,150 @@
+package hooks
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+// on_stop run history: every on_stop hook that actually runs (not skipped,
+// not replayed from cache) appends one JSON line to a per-repo history under
+// StateDir()/hooks/history, keyed like the flakiness history. `grove hooks
+// history` summarizes it per hook: pass rate, p50/p95 duration and the last
+// failure.
+
+// HookRunRecord is one hook run in the history.
+type HookRunRecord struct {
+	Hook            string    `json:"hook"`
+	WorkingDir      string    `json:"working_dir"`
+	SessionID       string    `json:"session_id,omitempty"`
+	NativeSessionID string    `json:"native_session_id,omitempty"`
+	RunID           string    `json:"run_id,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Status          string    `json:"status"`
+	ExitCode        int       `json:"exit_code"`
+	Attempts        int       `json:"attempts"`
+	LogPath         string    `json:"log_path"`
+	Commit          string    `json:"commit,omitempty"`
+}
+
+// Duration is the wall time of the run, across all attempts.
+func (r HookRunRecord) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// HookHistoryDir is the root of the on_stop run history.
+func HookHistoryDir() string {
+	return filepath.Join(paths.StateDir(), "hooks", "history")
+}
+
+// HookHistoryPath is the history file for the repo at workingDir.
+func HookHistoryPath(workingDir string) string {
+	return repoStateFile("history", workingDir, ".jsonl")
+}
+
+// appendHookHistory appends a run to its repo's history.
+func appendHookHistory(record HookRunRecord) {
+	path := HookHistoryPath(record.WorkingDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err == nil {
+		defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	}
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// ReadHookHistory reads a history file, skipping malformed lines.
+func ReadHookHistory(path string) ([]HookRunRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []HookRunRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record HookRunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Hook == "" {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// HookStats summarizes one hook's runs.
+type HookStats struct {
+	Hook        string         `json:"hook"`
+	Runs        int            `json:"runs"`
+	Passed      int            `json:"passed"`
+	PassRate    float64        `json:"pass_rate"`
+	P50         time.Duration  `json:"p50_ns"`
+	P95         time.Duration  `json:"p95_ns"`
+	LastFailure *HookRunRecord `json:"last_failure,omitempty"`
+}
+
+// SummarizeHookHistory groups records by hook and returns their stats,
+// slowest p95 first.
+func SummarizeHookHistory(records []HookRunRecord) []HookStats {
+	byHook := map[string][]HookRunRecord{}
+	for _, r := range records {
+		byHook[r.Hook] = append(byHook[r.Hook], r)
+	}
+
+	stats := make([]HookStats, 0, len(byHook))
+	for hook, runs := range byHook {
+		s := HookStats{Hook: hook, Runs: len(runs)}
+		durations := make([]time.Duration, 0, len(runs))
+		for _, r := range runs {
+			durations = append(durations, r.Duration())
+			if hookPassed(r.Status) {
+				s.Passed++
+				continue
+			}
+			if s.LastFailure == nil || r.FinishedAt.After(s.LastFailure.FinishedAt) {
+				s.LastFailure = &r
+			}
+		}
+		s.PassRate = float64(s.Passed) / float64(s.Runs)
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		s.P50 = percentile(durations, 0.50)
+		s.P95 = percentile(durations, 0.95)
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].P95 != stats[j].P95 {
+			return stats[i].P95 > stats[j].P95
+		}
+		return stats[i].Hook < stats[j].Hook
+	})
+	return stats
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExecuteAsyncHooksAppendsHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := initGitRepo(t), t.TempDir()
+
+	stop := &onStopContext{WorkingDir: work, SessionID: "grove-1", NativeSessionID: "native-1"}
+	hooks := []OnStopHook{onStopHook("test", "true"), onStopHook("lint", "exit 3"), onStopHook("report", "true", "lint")}
+	executeAsyncHooks(hooks, 0, stop, stateDir)
+
+	records, err := ReadHookHistory(HookHistoryPath(work))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// report is skipped, so it has no record.
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(records), records)
+	}
+	byHook := map[string]HookRunRecord{}
+	for _, r := range records {
+		byHook[r.Hook] = r
+		if r.SessionID != "grove-1" || r.NativeSessionID != "native-1" || r.RunID == "" || r.Commit == "" || r.Attempts != 1 {
+			t.Errorf("%s: missing run context: %+v", r.Hook, r)
+		}
+		if r.FinishedAt.Before(r.StartedAt) || r.LogPath == "" {
+			t.Errorf("%s: bad timing or log path: %+v", r.Hook, r)
+		}
+	}
+	if r := byHook["lint"]; r.Status != "failed" || r.ExitCode != 3 {
+		t.Errorf("lint = %s exit %d, want failed exit 3", r.Status, r.ExitCode)
+	}
+	if r := byHook["test"]; r.Status != "passed" || r.ExitCode != 0 {
+		t.Errorf("test = %s exit %d, want passed exit 0", r.Status, r.ExitCode)
+	}
+}
+
+func TestSummarizeHookHistory(t *testing.T) {
+	base := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	run := func(hook, status string, seconds, minute int) HookRunRecord {
+		start := base.Add(time.Duration(minute) * time.Minute)
+		return HookRunRecord{Hook: hook, Status: status, StartedAt: start, FinishedAt: start.Add(time.Duration(seconds) * time.Second)}
+	}
+	var records []HookRunRecord
+	for i := 1; i <= 20; i++ {
+		records = append(records, run("test", "passed", i, i))
+	}
+	records[4].Status = "failed"
+	records[9].Status = "killed"
+	records[11].Status = statusPassedAfterRetry
+	records = append(records, run("lint", "passed", 90, 0), run("lint", "passed", 2, 1))
+
+	stats := SummarizeHookHistory(records)
+	if len(stats) != 2 || stats[0].Hook != "lint" {
+		t.Fatalf("want lint (slowest p95) first, got %+v", stats)
+	}
+	lint, test := stats[0], stats[1]
+	if lint.P50 != 2*time.Second || lint.P95 != 90*time.Second || lint.PassRate != 1 || lint.LastFailure != nil {
+		t.Errorf("lint stats = %+v", lint)
+	}
+	if test.Runs != 20 || test.Passed != 18 || test.PassRate != 0.9 {
+		t.Errorf("test pass counts = %d/%d (%v)", test.Passed, test.Runs, test.PassRate)
+	}
+	if test.P50 != 10*time.Second || test.P95 != 19*time.Second {
+		t.Errorf("test p50/p95 = %s/%s, want 10s/19s", test.P50, test.P95)
+	}
+	if test.LastFailure == nil || test.LastFailure.Status != "killed" {
+		t.Errorf("last failure = %+v, want the killed run", test.LastFailure)
+	}
+}
@@ -168,8 +168,19 @@ func RunPreToolUseHook() {
 		workingDir = envVar
 	}
 
-	// Apply tool-specific validation
-	response := validateTool(data.ToolName, data.ToolInput, workingDir)
+	// Apply the repo's pre_tool_use policy. The payload cwd locates grove.toml
+	// when the tool input carries no explicit working directory.
+	policyDir := workingDir
+	if policyDir == "" {
+		policyDir = resolveWorkingDir(data.Cwd)
+	}
+	response := validateTool(data.ToolName, data.ToolInput, ctx.RawInput, policyDir)
+
+	// A bash_rewrite rule may have replaced the input; record what will run.
+	toolInput := data.ToolInput
+	if out := response.HookSpecificOutput; out != nil && out.UpdatedInput != nil {
+		toolInput = out.UpdatedInput
+	}
 
 	// Log the event
 	eventData := map[string]any{
@@ -179,6 +190,12 @@ func RunPreToolUseHook() {
 		"blocked_reason": response.Message,
 		"working_dir":    workingDir,
 	}
+	if response.HookSpecificOutput != nil {
+		eventData["permission_decision"] = response.HookSpecificOutput.PermissionDecision
+		if response.HookSpecificOutput.UpdatedInput != nil {
+			eventData["updated_input"] = response.HookSpecificOutput.UpdatedInput
+		}
+	}
 
 	if err := ctx.LogEvent(models.EventPreToolUse, eventData); err != nil {
 		log.Printf("Failed to log event: %v", err)
@@ -196,7 +213,10 @@ func RunPreToolUseHook() {
 		}
 		linkID := newCommandLinkID(data.SessionID)
 		storeCommandLinkID(data.SessionID, linkID)
-		if entry, ok := buildPreCommandEntry(data.ToolName, data.ToolInput, linkID, preCwd, time.Now()); ok {
+		if entry, ok := buildPreCommandEntry(data.ToolName, toolInput, linkID, preCwd, time.Now()); ok {
+			if original, _ := extractBashCommand(data.ToolInput); original != entry.Command {
+				entry.OriginalCommand = original
+			}
 			appendCommandEntries(data.SessionID, []commandEntry{entry})
 		}
 	}
@@ -218,8 +238,8 @@ func RunPreToolUseHook() {
 		// Generate a simple tool ID
 		toolID = fmt.Sprintf("%s_%d", data.SessionID, time.Now().UnixNano())
 
-		// Use the tool input as parameters
-		args := data.ToolInput
+		// Use the (possibly rewritten) tool input as parameters
+		args := toolInput
 
 		tool := &models.ToolExecution{
 			ID:            toolID,
@@ -371,7 +391,9 @@ func dispatchPostToolUseReminders(data PostToolUseInput) {
 		return
 	}
 
-	var hooksConfig config.HooksConfig
+	var hooksConfig struct {
+		PostToolUse []PostToolUseReminder `yaml:"post_tool_use"`
+	}
 	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
 		return
 	}
@@ -379,29 +401,45 @@ func dispatchPostToolUseReminders(data PostToolUseInput) {
 		return
 	}
 
+	// A nil input still matches input-free rules such as mcp__server.
 	toolInput, _ := data.ToolInput.(map[string]any)
-	if toolInput == nil {
-		return
-	}
 
-	var contexts []string
-	var matchedNames []string
+	var matches []reminderMatch
+	var vars *reminderVars
 	for _, entry := range hooksConfig.PostToolUse {
-		if entry.If == "" || entry.AdditionalContext == "" {
+		if entry.If == "" || (entry.AdditionalContext == "" && entry.IncludeFile == "") {
+			continue
+		}
+		if !evaluatePermissionRule(entry.If, data.ToolName, toolInput, workingDir) || !entry.matchesOutcome(data) {
 			continue
 		}
-		if !evaluatePermissionRule(entry.If, data.ToolName, toolInput) {
+		if vars == nil {
+			v := newReminderVars(data.SessionID, data.ToolName, toolInput, workingDir)
+			vars = &v
+		}
+		text := entry.renderReminder(*vars)
+		if text == "" {
 			continue
 		}
-		contexts = append(contexts, entry.AdditionalContext)
-		matchedNames = append(matchedNames, entry.Name)
+		matches = append(matches, reminderMatch{Entry: entry, Text: text})
 	}
 
-	if len(contexts) == 0 {
+	if len(matches) == 0 {
 		return
 	}
 
-	logPostToolUseReminders(data, matchedNames)
+	applyReminderLimits(data.SessionID, matches, vars.FilePath, time.Now())
+	logPostToolUseReminders(data, matches)
+
+	var contexts []string
+	for _, m := range matches {
+		if m.Suppressed == "" {
+			contexts = append(contexts, m.Text)
+		}
+	}
+	if len(contexts) == 0 {
+		return
+	}
 
 	response := map[string]any{
 		"hookSpecificOutput": map[string]any{
@@ -417,8 +455,9 @@ func dispatchPostToolUseReminders(data PostToolUseInput) {
 }
 
 // logPostToolUseReminders appends one line per matched reminder to the
-// per-session post_tool_use.log under the grove hooks state dir.
-func logPostToolUseReminders(data PostToolUseInput, matched []string) {
+// per-session post_tool_use.log under the grove hooks state dir, including
+// deliveries a rate limit suppressed.
+func logPostToolUseReminders(data PostToolUseInput, matches []reminderMatch) {
 	if data.SessionID == "" {
 		return
 	}
@@ -440,8 +479,12 @@ func logPostToolUseReminders(data PostToolUseInput, matched []string) {
 		}
 	}
 	ts := time.Now().Format(time.RFC3339)
-	for _, name := range matched {
-		fmt.Fprintf(f, "[%s] hook=%s tool=%s input_summary=%s\n", ts, name, data.ToolName, summary)
+	for _, m := range matches {
+		if m.Suppressed != "" {
+			fmt.Fprintf(f, "[%s] hook=%s tool=%s suppressed=%q input_summary=%s\n", ts, m.Entry.Name, data.ToolName, m.Suppressed, summary)
+			continue
+		}
+		fmt.Fprintf(f, "[%s] hook=%s tool=%s input_summary=%s\n", ts, m.Entry.Name, data.ToolName, summary)
 	}
 }
 
@@ -560,6 +603,11 @@ func RunStopHook() {
 		}).Error("Error initializing hook context")
 		os.Exit(1)
 	}
+	// A blocked stop is not a stop: the session keeps running, so the
+	// bookkeeping pipeline is skipped.
+	if runStopGatesForInput(ctx, ctx.RawInput) {
+		return
+	}
 	runStopPipeline(ctx, slog)
 }
 
@@ -961,7 +1009,8 @@ func writeHeadlessFallbackStatus(slog *logrus.Entry, jobFilePath, jobID string,
 // the first PreToolUse (the empty-registry bug class) — and records the
 // transcript path before any tool runs. SessionStart supports a response
 // contract (additionalContext), so stdout must stay pristine: this handler
-// writes nothing to stdout.
+// writes nothing to stdout except the opt-in job briefing (see
+// session_briefing.go).
 func RunSessionStartHook() {
 	ctx, err := NewHookContext()
 	if err != nil {
@@ -975,9 +1024,34 @@ func RunSessionStartHook() {
 		os.Exit(1)
 	}
 
+	// Built before registration, which may replace a stale session dir
+	// holding the previous run's on_stop results.
+	briefing := ""
+	workingDir := data.Cwd
+	if workingDir == "" {
+		workingDir = os.Getenv("PWD")
+	}
+	if sessionBriefingEnabled(workingDir) {
+		briefing = buildSessionBriefing(data.SessionID)
+	}
+
 	if err := ctx.EnsureSessionExists(data.SessionID, data.TranscriptPath); err != nil {
 		log.Printf("Failed to ensure session exists: %v", err)
 	}
+
+	if briefing == "" {
+		return
+	}
+	payload, err := json.Marshal(SessionStartResponse{
+		HookSpecificOutput: SessionStartHookOutput{
+			HookEventName:     "SessionStart",
+			AdditionalContext: briefing,
+		},
+	})
+	if err != nil {
+		return
+	}
+	fmt.Print(string(payload))
 }
 
 // RunSubagentStartHook handles the SubagentStart hook (fires for both
@@ -1130,7 +1204,7 @@ func ExecuteRepoHookCommands(hc *HookContext, workingDir string) error {
 	}
 
 	// Unmarshal hooks from extensions
-	var hooksConfig config.HooksConfig
+	var hooksConfig OnStopConfig
 	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
 		slog.WithFields(logrus.Fields{
 			"working_dir": workingDir,
@@ -1148,27 +1222,22 @@ func ExecuteRepoHookCommands(hc *HookContext, workingDir string) error {
 		"working_dir": workingDir,
 	}).Info("Found on_stop commands in grove.yml")
 
+	var data StopInput
+	_ = json.Unmarshal(hc.RawInput, &data)
+	stop := newOnStopContext(data, workingDir)
+
 	for _, hookCmd := range hooksConfig.OnStop {
-		// Check run_if condition
-		if hookCmd.RunIf == "changes" {
-			hasChanges, err := hasGitChanges(workingDir)
-			if err != nil {
-				slog.WithFields(logrus.Fields{
-					"name":  hookCmd.Name,
-					"error": err.Error(),
-				}).Warn("Failed to check git changes, skipping command")
-				continue
-			}
-			if !hasChanges {
-				slog.WithFields(logrus.Fields{
-					"name": hookCmd.Name,
-				}).Debug("Skipping command - no git changes detected")
-				continue
-			}
+		// Check run_if conditions (shared with stop-async, see run_if.go)
+		if reason := stop.skipReason(hookCmd); reason != "" {
+			slog.WithFields(logrus.Fields{
+				"name":   hookCmd.Name,
+				"reason": reason,
+			}).Debug("Skipping command - run_if conditions not met")
+			continue
 		}
 
 		// Execute the command
-		if err := ExecuteHookCommand(workingDir, hookCmd); err != nil {
+		if err := ExecuteHookCommand(workingDir, hookCmd.HookCommand); err != nil {
 			slog.WithFields(logrus.Fields{
 				"name":  hookCmd.Name,
 				"error": err.Error(),
@@ -0,0 +1,198 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Incremental on_stop hooks: every hook runs with
+//
+//	GROVE_SESSION_ID         the session (flow job) id
+//	GROVE_JOB_FILE           the linked flow job file, if any
+//	GROVE_PLAN_DIR           the plan directory holding the job file, if any
+//	GROVE_CHANGED_FILES_FILE a temp file listing changed files, one per line
+//	GROVE_CHANGED_FILES      the same list newline-separated (omitted when
+//	                         longer than maxChangedFilesEnv)
+//
+// so a linter can check only what was touched:
+//
+//	[[hooks.on_stop]]
+//	name = "lint"
+//	command = 'grep "\.go$" "$GROVE_CHANGED_FILES_FILE" | xargs -r gofumpt -l'
+//
+// The list holds the files changed since the hook last passed in this
+// session: modified events in the job's accessed_files.jsonl, files changed
+// in commits since then, and the current staged, unstaged and untracked
+// files. Before a hook's first pass it covers the whole session, with the
+// commits made since the session's started_at in metadata.json. Paths are
+// relative to the working directory; deleted files and files outside it are
+// left out. The checkpoint is <slug>.last_pass in the hook's state dir.
+
+// maxChangedFilesEnv caps GROVE_CHANGED_FILES so a large change set cannot
+// exceed the kernel's per-variable size limit.
+const maxChangedFilesEnv = 32 * 1024
+
+// hookCheckpoint is the start of a hook's last passing run.
+type hookCheckpoint struct {
+	StartedAt time.Time `json:"started_at"`
+	Head      string    `json:"head,omitempty"`
+}
+
+// readHookCheckpoint returns the checkpoint at path, or the zero value when
+// the hook has not passed yet.
+func readHookCheckpoint(path string) hookCheckpoint {
+	var cp hookCheckpoint
+	if content, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(content, &cp)
+	}
+	return cp
+}
+
+// writeHookCheckpoint records a passing run.
+func writeHookCheckpoint(path string, cp hookCheckpoint) {
+	if content, err := json.Marshal(cp); err == nil {
+		_ = os.WriteFile(path, content, 0o644)
+	}
+}
+
+// gitOutput runs git in dir and returns its trimmed stdout, or "" on error.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// changedFilesSince lists the files changed since the checkpoint, relative to
+// the working directory.
+func (c *onStopContext) changedFilesSince(cp hookCheckpoint) []string {
+	// git reports symlink-resolved paths, so compare resolved paths.
+	base := c.WorkingDir
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+	seen := map[string]bool{}
+	var files []string
+	add := func(path string) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.WorkingDir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") || seen[rel] {
+			return
+		}
+		seen[rel] = true
+		files = append(files, rel)
+	}
+
+	for _, path := range c.modifiedSince(cp.StartedAt) {
+		add(path)
+	}
+
+	// git paths are relative to the repository root.
+	if root := gitOutput(c.WorkingDir, "rev-parse", "--show-toplevel"); root != "" {
+		var committed string
+		switch {
+		case cp.Head != "":
+			committed = gitOutput(c.WorkingDir, "diff", "--name-only", cp.Head, "HEAD")
+		case !c.SessionStartedAt.IsZero():
+			since := c.SessionStartedAt.UTC().Format(time.RFC3339)
+			committed = gitOutput(c.WorkingDir, "log", "--since="+since, "--name-only", "--format=", "HEAD")
+		}
+		if committed != "" {
+			for _, path := range strings.Split(committed, "\n") {
+				if path != "" {
+					add(filepath.Join(root, path))
+				}
+			}
+		}
+		if dirty, err := gitChangedFiles(c.WorkingDir); err == nil {
+			for _, path := range dirty {
+				add(filepath.Join(root, path))
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+// modifiedSince returns the paths of modified events recorded in the job's
+// accessed_files.jsonl at or after since (second precision).
+func (c *onStopContext) modifiedSince(since time.Time) []string {
+	if c.PlanDir == "" || c.SessionID == "" {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(c.PlanDir, ".artifacts", c.SessionID, "accessed_files.jsonl"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	since = since.Truncate(time.Second)
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry fileAccessEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Action != "modified" {
+			continue
+		}
+		if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil && ts.Before(since) {
+			continue
+		}
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+// hookEnv returns the GROVE_* variables for a hook run with the given
+// changed files, whose list was written to changedFilesPath.
+func (c *onStopContext) hookEnv(changedFilesPath string, files []string) []string {
+	env := []string{
+		"GROVE_SESSION_ID=" + c.SessionID,
+		"GROVE_JOB_FILE=" + c.JobFilePath,
+		"GROVE_PLAN_DIR=" + c.PlanDir,
+		"GROVE_CHANGED_FILES_FILE=" + changedFilesPath,
+	}
+	if list := strings.Join(files, "\n"); len(list) <= maxChangedFilesEnv {
+		env = append(env, "GROVE_CHANGED_FILES="+list)
+	}
+	return env
+}
+
+// writeChangedFilesList writes files, one per line, to a new temp file and
+// returns its path.
+func writeChangedFilesList(files []string) (string, error) {
+	f, err := os.CreateTemp("", "grove-changed-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	for _, file := range files {
+		buf.WriteString(file)
+		buf.WriteByte('\n')
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
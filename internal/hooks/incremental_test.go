@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+func TestChangedFilesSince(t *testing.T) {
+	repo := initGitRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(repo, "a.go"), "package a\n")
+	write(filepath.Join(repo, "old.go"), "package a\n")
+	write(filepath.Join(repo, "d.go"), "package a\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "a")
+	since := time.Now().Add(-time.Minute)
+	cp := hookCheckpoint{StartedAt: since, Head: gitOutput(repo, "rev-parse", "HEAD")}
+
+	write(filepath.Join(repo, "b.go"), "package a\n")
+	git("add", "b.go")
+	git("commit", "-q", "-m", "b")
+	write(filepath.Join(repo, "c.go"), "package a\n")
+	write(filepath.Join(repo, "gone.go"), "package a\n")
+	git("rm", "-q", "old.go")
+
+	planDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.go")
+	write(outside, "package b\n")
+	write(filepath.Join(repo, "e.go"), "package a\n")
+	write(filepath.Join(repo, "gone.go"), "package a\n")
+	before := since.Add(-time.Hour).Format(time.RFC3339)
+	after := since.Add(time.Second).Format(time.RFC3339)
+	write(filepath.Join(planDir, ".artifacts", "job-1", "accessed_files.jsonl"), strings.Join([]string{
+		`{"timestamp":"` + before + `","tool":"Edit","path":"` + filepath.Join(repo, "d.go") + `","action":"modified"}`,
+		`{"timestamp":"` + after + `","tool":"Read","path":"` + filepath.Join(repo, "a.go") + `","action":"read"}`,
+		`{"timestamp":"` + after + `","tool":"Edit","path":"e.go","action":"modified"}`,
+		`{"timestamp":"` + after + `","tool":"Edit","path":"` + outside + `","action":"modified"}`,
+	}, "\n")+"\n")
+	if err := os.Remove(filepath.Join(repo, "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := &onStopContext{WorkingDir: repo, SessionID: "job-1", PlanDir: planDir}
+	got := strings.Join(stop.changedFilesSince(cp), ",")
+	if want := "b.go,c.go,e.go"; got != want {
+		t.Fatalf("changed files = %s, want %s", got, want)
+	}
+
+	// Without a checkpoint (first run) the whole session counts.
+	got = strings.Join(stop.changedFilesSince(hookCheckpoint{}), ",")
+	if want := "c.go,d.go,e.go"; got != want {
+		t.Fatalf("first-run changed files = %s, want %s", got, want)
+	}
+}
+
+func TestChangedFilesSinceSessionCommits(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	repo := initGitRepo(t)
+	commit := func(name, date string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("package a\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", name}, {"commit", "-q", "-m", name}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo
+			cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v: %s", args, err, out)
+			}
+		}
+	}
+
+	now := time.Now()
+	commit("before.go", now.Add(-time.Hour).Format(time.RFC3339))
+	sessionDir := filepath.Join(paths.StateDir(), "hooks", "sessions", "job-1")
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := `{"session_id":"job-1","started_at":"` + now.Add(-time.Minute).Format(time.RFC3339) + `"}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "metadata.json"), []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commit("during.go", now.Format(time.RFC3339))
+
+	// The session committed during.go before the hook's first pass; the
+	// commit is picked up from started_at even though the tree is clean.
+	stop := newOnStopContext(StopInput{SessionID: "job-1"}, repo)
+	if got := strings.Join(stop.changedFilesSince(hookCheckpoint{}), ","); got != "during.go" {
+		t.Fatalf("first-run changed files = %s, want during.go", got)
+	}
+}
+
+func TestRunSingleAsyncHookChangedFilesEnv(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	repo := initGitRepo(t)
+	stateDir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "env.txt")
+
+	if err := os.WriteFile(filepath.Join(repo, "x.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hook := onStopHook("lint", `{ echo "$GROVE_SESSION_ID|$GROVE_JOB_FILE|$GROVE_PLAN_DIR|$GROVE_CHANGED_FILES"; cat "$GROVE_CHANGED_FILES_FILE"; } > `+out)
+	stop := &onStopContext{WorkingDir: repo, SessionID: "job-1", JobFilePath: "/plans/p/01-x.md", PlanDir: "/plans/p"}
+	if r := runSingleAsyncHook(hook, stop, stateDir); r.Status != "passed" {
+		t.Fatalf("status = %q", r.Status)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "job-1|/plans/p/01-x.md|/plans/p|x.go\nx.go\n"; string(content) != want {
+		t.Fatalf("hook saw %q, want %q", content, want)
+	}
+	if cp := readHookCheckpoint(filepath.Join(stateDir, "lint.last_pass")); cp.StartedAt.IsZero() || cp.Head == "" {
+		t.Fatalf("checkpoint = %+v", cp)
+	}
+}
@@ -0,0 +1,25 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests from a scratch directory: loggers write to
+// .grove/logs under the working directory's workspace, which would otherwise
+// be this repo.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "hooks-test-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,141 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/grovetools/core/config"
+	"github.com/grovetools/core/pkg/daemon"
+)
+
+// Manual on_stop runs: `grove hooks run` evaluates and runs the on_stop hooks
+// outside a Stop event. Hooks go through the same gates as in stop-async and
+// write the same artifacts, to the given session's on_stop dir or, without a
+// session, to StateDir()/hooks/sessions/manual/on_stop. When only some hooks
+// are named, needs on the others are dropped rather than pulling them in.
+
+// manualSessionID is the session dir of runs not tied to a session.
+const manualSessionID = "manual"
+
+// LoadOnStopConfig decodes the on_stop part of the hooks extension of the
+// grove config at workingDir.
+func LoadOnStopConfig(workingDir string) (OnStopConfig, error) {
+	var hooksConfig OnStopConfig
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return hooksConfig, fmt.Errorf("load grove config: %w", err)
+	}
+	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
+		return hooksConfig, fmt.Errorf("unmarshal hooks config: %w", err)
+	}
+	return hooksConfig, nil
+}
+
+// SelectOnStopHooks returns the named hooks in config order, or all of them
+// when no names are given. needs on hooks that were not selected are dropped.
+func SelectOnStopHooks(hooks []OnStopHook, names []string) ([]OnStopHook, error) {
+	if len(names) == 0 {
+		return hooks, nil
+	}
+	available := make([]string, 0, len(hooks))
+	for _, hc := range hooks {
+		available = append(available, hc.Name)
+	}
+	for _, name := range names {
+		if !slices.Contains(available, name) {
+			sort.Strings(available)
+			return nil, fmt.Errorf("hook %q not found in [[hooks.on_stop]]; available: %s", name, strings.Join(available, ", "))
+		}
+	}
+
+	var selected []OnStopHook
+	for _, hc := range hooks {
+		if !slices.Contains(names, hc.Name) {
+			continue
+		}
+		hc.Needs = slices.DeleteFunc(slices.Clone(hc.Needs), func(need string) bool {
+			return !slices.Contains(names, need)
+		})
+		selected = append(selected, hc)
+	}
+	return selected, nil
+}
+
+// OnStopPlanEntry is what a manual run would do with one hook.
+type OnStopPlanEntry struct {
+	Name   string   `json:"name"`
+	Run    bool     `json:"run"`
+	Reason string   `json:"reason,omitempty"`
+	Needs  []string `json:"needs,omitempty"`
+}
+
+// PlanOnStopHooks evaluates every hook's gates for a manual run in
+// workingDir without running it. A hook that would run has Run set and, when
+// its result would be replayed from the cache, a Reason saying so.
+// run_if_command predicates are executed.
+func PlanOnStopHooks(workingDir, sessionID string, hooks []OnStopHook) ([]OnStopPlanEntry, error) {
+	stop, err := newManualOnStopContext(workingDir, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	_, invalid := resolveOnStopNeeds(hooks)
+	plan := make([]OnStopPlanEntry, 0, len(hooks))
+	for i, hc := range hooks {
+		entry := OnStopPlanEntry{Name: hc.Name, Needs: hc.Needs}
+		switch reason := stop.gateReason(hc); {
+		case invalid[i] != "":
+			entry.Reason = invalid[i]
+		case reason != "":
+			entry.Reason = reason
+		default:
+			entry.Run = true
+			if hc.Cache {
+				if key, err := hookCacheKey(hc, workingDir); err == nil {
+					if cached, ok := loadHookCache(slugifyHookName(hc.Name), key); ok {
+						entry.Reason = "replays cached " + cached.Status
+					}
+				}
+			}
+		}
+		plan = append(plan, entry)
+	}
+	return plan, nil
+}
+
+// RunOnStopHooks runs hooks as stop-async would for a stop in workingDir and
+// returns the state dir holding their artifacts and the aggregate exit code.
+// Failure output goes to stderr.
+func RunOnStopHooks(workingDir, sessionID string, hooks []OnStopHook, maxParallel int) (string, int, error) {
+	stop, err := newManualOnStopContext(workingDir, sessionID)
+	if err != nil {
+		return "", 0, err
+	}
+	stateDir := OnStopStateDir(manualSessionID)
+	if sessionID != "" {
+		stateDir = OnStopStateDir(stop.NativeSessionID)
+		// Only a real session has a row in the browser to update.
+		stop.Daemon = daemon.New()
+	}
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return "", 0, fmt.Errorf("create state dir: %w", err)
+	}
+	return stateDir, executeAsyncHooks(hooks, maxParallel, stop, stateDir), nil
+}
+
+// newManualOnStopContext describes a manual run, taking the session's job,
+// type and provider from its metadata when a session id is given. An id that
+// matches no known session is an error rather than a new session dir.
+func newManualOnStopContext(workingDir, sessionID string) (*onStopContext, error) {
+	data := StopInput{}
+	if sessionID != "" {
+		nativeID, err := ResolveSessionID(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		data.SessionID = nativeID
+	}
+	return newOnStopContext(data, workingDir), nil
+}
@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSelectOnStopHooks(t *testing.T) {
+	hooks := []OnStopHook{
+		onStopHook("build", "true"),
+		onStopHook("lint", "true", "build"),
+		onStopHook("test", "true", "build", "lint"),
+	}
+
+	all, err := SelectOnStopHooks(hooks, nil)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("no names: got %d hooks, %v", len(all), err)
+	}
+
+	selected, err := SelectOnStopHooks(hooks, []string{"test", "lint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || selected[0].Name != "lint" || selected[1].Name != "test" {
+		t.Fatalf("selected = %+v, want lint, test in config order", selected)
+	}
+	if len(selected[0].Needs) != 0 || !slices.Equal(selected[1].Needs, []string{"lint"}) {
+		t.Errorf("needs = %v, %v; want unselected build dropped", selected[0].Needs, selected[1].Needs)
+	}
+	if !slices.Equal(hooks[2].Needs, []string{"build", "lint"}) {
+		t.Errorf("config needs modified: %v", hooks[2].Needs)
+	}
+
+	if _, err := SelectOnStopHooks(hooks, []string{"missing"}); err == nil || !strings.Contains(err.Error(), "available: build, lint, test") {
+		t.Errorf("unknown hook error = %v", err)
+	}
+}
+
+func TestPlanOnStopHooks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	t.Setenv("GROVE_TEST_ENABLE", "")
+	work := t.TempDir()
+
+	optIn := onStopHook("e2e", "true")
+	optIn.EnableEnv = "GROVE_TEST_ENABLE"
+	conditional := onStopHook("check", "true")
+	conditional.RunIfCommand = "exit 1"
+	hooks := []OnStopHook{
+		onStopHook("lint", "true"),
+		onStopHook("test", "true", "lint"),
+		onStopHook("slow", "true"),
+		optIn,
+		conditional,
+	}
+	if err := DisableHook(work, "slow", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanOnStopHooks(work, "", hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []OnStopPlanEntry{
+		{Name: "lint", Run: true},
+		{Name: "test", Run: true, Needs: []string{"lint"}},
+		{Name: "slow", Reason: "disabled"},
+		{Name: "e2e", Reason: "GROVE_TEST_ENABLE is not set"},
+		{Name: "check", Reason: "run_if_command exited 1"},
+	}
+	for i, entry := range plan {
+		w := want[i]
+		if entry.Name != w.Name || entry.Run != w.Run || entry.Reason != w.Reason || !slices.Equal(entry.Needs, w.Needs) {
+			t.Errorf("plan[%d] = %+v, want %+v", i, entry, w)
+		}
+	}
+	if _, err := os.Stat(OnStopStateDir(manualSessionID)); err == nil {
+		t.Error("dry run created artifacts")
+	}
+}
+
+func TestRunOnStopHooksWritesManualArtifacts(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	hooks := []OnStopHook{onStopHook("ok", "echo fine"), onStopHook("bad", "exit 1")}
+	stateDir, code, err := RunOnStopHooks(work, "", hooks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+	if stateDir != OnStopStateDir(manualSessionID) {
+		t.Errorf("state dir = %s, want the manual session dir", stateDir)
+	}
+	if got := lastSummary(t, stateDir, "ok"); got != "passed" {
+		t.Errorf("ok summary = %q", got)
+	}
+	if log, _ := os.ReadFile(filepath.Join(stateDir, "ok.log")); strings.TrimSpace(string(log)) != "fine" {
+		t.Errorf("ok log = %q", log)
+	}
+	if run, err := ReadOnStopRun(stateDir); err != nil || run.ExitCode() != 2 {
+		t.Errorf("run.json = %+v, %v", run, err)
+	}
+}
+
+func TestManualRunRejectsUnknownSession(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	hooks := []OnStopHook{onStopHook("ok", "true")}
+	if _, err := PlanOnStopHooks(work, "no-such-session", hooks); err == nil {
+		t.Error("plan accepted an unknown session")
+	}
+	if _, _, err := RunOnStopHooks(work, "no-such-session", hooks, 0); err == nil {
+		t.Error("run accepted an unknown session")
+	}
+	if _, err := os.Stat(OnStopStateDir("no-such-session")); err == nil {
+		t.Error("unknown session got a state dir")
+	}
+}
@@ -1,6 +1,8 @@
 package hooks
 
 import (
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -8,57 +10,224 @@ import (
 // evaluatePermissionRule reports whether a Claude Code permission-rule string
 // (e.g. "Bash(git commit *)" or "Edit(*.go)") matches a given tool call.
 //
-// Supported tool names: Bash, Edit, Write, Read, MultiEdit. For Bash the glob
-// is matched against each subcommand of tool_input.command split on '&&', ';',
-// '|'. For file tools the glob is matched against tool_input.file_path.
+// Supported rule forms, mirroring Claude Code's permission syntax:
+//
+//	Bash(glob)                matched against each program
+//	                          tool_input.command executes (see shell.go),
+//	                          so "Bash(rm -rf *)" also catches
+//	                          `cd x && bash -c "rm -rf y"`
+//	Edit/Write/Read/MultiEdit(glob)
+//	                          matched against tool_input.file_path
+//	NotebookEdit(glob)        matched against tool_input.notebook_path
+//	Glob(glob), Grep(glob)    matched against tool_input.path, the directory
+//	                          searched
+//	WebFetch(domain:host)     matched against the hostname of tool_input.url;
+//	                          host may be a glob such as *.example.com
+//	Agent(type)               matched against tool_input.subagent_type; also
+//	                          matches the legacy Task tool name
+//	mcp__server               every tool of an MCP server
+//	mcp__server__tool         one MCP tool; the tool part may be a glob
+//
+// Globs use the syntax described in glob.go. In Bash rules every wildcard
+// crosses '/', a trailing " *" also matches the bare command ("git push *"
+// matches "git push"), and the legacy ":*" suffix means the same. Path globs
+// are gitignore-style: "//abs", "~/home", "/project-root" and "./working-dir"
+// globs are anchored (see matchPathGlob); any other may match at any
+// directory boundary, so "*.go" and "src/**/*.go" both match
+// "/repo/src/a/b.go". A glob of "*" matches every call of the tool.
+//
+// A '!' at the start of the glob negates it: "Edit(!*.md)" matches Edit calls
+// whose file is not markdown. Rules combine with any_of(...) and all_of(...),
+// which take comma-separated rules and may nest:
+//
+//	all_of(Edit(src/**), Edit(!src/generated/**))
+//	any_of(Write({*.yaml,*.yml}), mcp__github)
 //
 // Empty rules, malformed rules, mismatched tool names, and unsupported tools
 // all return false.
-func evaluatePermissionRule(rule, toolName string, toolInput map[string]any) bool {
+func evaluatePermissionRule(rule, toolName string, toolInput map[string]any, workingDir string) bool {
+	rule = strings.TrimSpace(rule)
+	if inner, ok := compositeRule(rule, "any_of"); ok {
+		for _, sub := range splitTopLevel(inner, ',') {
+			if evaluatePermissionRule(sub, toolName, toolInput, workingDir) {
+				return true
+			}
+		}
+		return false
+	}
+	if inner, ok := compositeRule(rule, "all_of"); ok {
+		for _, sub := range splitTopLevel(inner, ',') {
+			if !evaluatePermissionRule(sub, toolName, toolInput, workingDir) {
+				return false
+			}
+		}
+		return strings.TrimSpace(inner) != ""
+	}
+	if strings.HasPrefix(rule, "mcp__") {
+		return matchMCPRule(rule, toolName)
+	}
 	ruleTool, glob, ok := parsePermissionRule(rule)
 	if !ok {
 		return false
 	}
-	if ruleTool != toolName {
+	if ruleTool != toolName && !(isAgentSpawnTool(ruleTool) && isAgentSpawnTool(toolName)) {
+		return false
+	}
+	negate := strings.HasPrefix(glob, "!")
+	if negate {
+		glob = glob[1:]
+	}
+	matched, applicable := matchToolArgument(toolName, glob, toolInput, workingDir)
+	if !applicable {
 		return false
 	}
+	return matched != negate
+}
+
+// compositeRule returns the argument list of a name(...) composite rule.
+func compositeRule(rule, name string) (string, bool) {
+	if !strings.HasPrefix(rule, name+"(") || !strings.HasSuffix(rule, ")") {
+		return "", false
+	}
+	return rule[len(name)+1 : len(rule)-1], true
+}
 
+// matchToolArgument matches a rule's glob against the relevant field of a
+// tool call. applicable=false means the tool is unsupported or the call lacks
+// the field, so neither the rule nor its negation can match.
+func matchToolArgument(toolName, glob string, toolInput map[string]any, workingDir string) (matched, applicable bool) {
+	if glob == "*" {
+		return true, true
+	}
 	switch toolName {
 	case "Bash":
 		cmd, _ := toolInput["command"].(string)
 		if cmd == "" {
-			return false
+			return false, false
 		}
-		for _, sub := range splitShellCommand(cmd) {
-			sub = strings.TrimSpace(sub)
-			if sub == "" {
-				continue
-			}
-			if matched, err := filepath.Match(glob, sub); err == nil && matched {
-				return true
+		for _, sub := range parseShellCommands(cmd) {
+			for _, text := range shellCommandStrings(sub) {
+				if matchBashGlob(glob, text) {
+					return true, true
+				}
 			}
 		}
-		return false
+		return false, true
 	case "Edit", "Write", "Read", "MultiEdit":
-		path, _ := toolInput["file_path"].(string)
-		if path == "" {
-			return false
+		return matchPathArgument(glob, stringField(toolInput, "file_path"), workingDir)
+	case "NotebookEdit":
+		return matchPathArgument(glob, stringField(toolInput, "notebook_path"), workingDir)
+	case "Glob", "Grep":
+		return matchPathArgument(glob, stringField(toolInput, "path"), workingDir)
+	case "WebFetch":
+		domain, ok := strings.CutPrefix(glob, "domain:")
+		if !ok {
+			return false, false
 		}
-		if matched, err := filepath.Match(glob, path); err == nil && matched {
-			return true
+		u, err := url.Parse(stringField(toolInput, "url"))
+		if err != nil || u.Hostname() == "" {
+			return false, false
 		}
-		// Fall back to matching against the basename when the glob has no
-		// path separator — this lets "Edit(*.go)" match "/abs/path/foo.go"
-		// the way Claude Code's permission rules intuitively work.
-		if !strings.ContainsRune(glob, '/') {
-			if matched, err := filepath.Match(glob, filepath.Base(path)); err == nil && matched {
-				return true
-			}
+		return matchGlob(strings.ToLower(domain), strings.ToLower(u.Hostname()), false), true
+	case "Agent", "Task":
+		agentType := stringField(toolInput, "subagent_type")
+		if agentType == "" {
+			return false, false
 		}
-		return false
+		return matchGlob(glob, agentType, false), true
 	default:
+		return false, false
+	}
+}
+
+// matchBashGlob matches a Bash rule glob against one subcommand.
+func matchBashGlob(glob, cmd string) bool {
+	if prefix, ok := strings.CutSuffix(glob, ":*"); ok {
+		glob = prefix + " *"
+	}
+	if matchGlob(glob, cmd, false) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(glob, " *"); ok {
+		return matchGlob(prefix, cmd, false)
+	}
+	return false
+}
+
+// matchPathArgument matches a path glob against a file-tool path.
+func matchPathArgument(glob, path, workingDir string) (matched, applicable bool) {
+	if path == "" {
+		return false, false
+	}
+	return matchPathGlob(glob, path, workingDir), true
+}
+
+// matchPathGlob matches a gitignore-style path glob. Anchored globs must
+// match the whole path; others may also match any suffix of the path that
+// starts at a directory boundary, which covers the basename. As in Claude
+// Code's permission rules, a glob is anchored by its prefix:
+//
+//	//path   absolute: "//etc/**" is /etc/**
+//	~/path   relative to the home directory
+//	/path    relative to the project root, the working dir's git top level
+//	./path   relative to the working dir
+func matchPathGlob(glob, path, workingDir string) bool {
+	anchored := true
+	switch {
+	case strings.HasPrefix(glob, "//"):
+		glob = glob[1:]
+	case strings.HasPrefix(glob, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		glob = joinGlob(home, glob[2:])
+	case strings.HasPrefix(glob, "/"):
+		glob = joinGlob(resolveRepoIdentity(workingDir).Top, glob[1:])
+	case strings.HasPrefix(glob, "./"):
+		dir, err := filepath.Abs(workingDir)
+		if err != nil {
+			return false
+		}
+		glob = joinGlob(dir, glob[2:])
+	default:
+		anchored = false
+	}
+	if !filepath.IsAbs(path) && workingDir != "" {
+		path = filepath.Join(workingDir, path)
+	}
+	if matchGlob(glob, path, true) {
+		return true
+	}
+	if anchored {
+		return false
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && matchGlob(glob, path[i+1:], true) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinGlob prefixes glob with dir. It does not clean the result, which could
+// rewrite the glob's own syntax.
+func joinGlob(dir, glob string) string {
+	return strings.TrimSuffix(dir, "/") + "/" + glob
+}
+
+// matchMCPRule matches an MCP rule against a tool name of the form
+// mcp__<server>__<tool>. A rule naming only the server ("mcp__github")
+// matches all of that server's tools; otherwise the rule is a glob over the
+// full tool name ("mcp__github__*", "mcp__github__get_issue").
+func matchMCPRule(rule, toolName string) bool {
+	if !strings.HasPrefix(toolName, "mcp__") {
 		return false
 	}
+	if !strings.Contains(strings.TrimPrefix(rule, "mcp__"), "__") {
+		return strings.HasPrefix(toolName, rule+"__")
+	}
+	return matchGlob(rule, toolName, false)
 }
 
 // parsePermissionRule splits a rule like "Bash(git commit *)" into ("Bash",
@@ -74,19 +243,3 @@ func parsePermissionRule(rule string) (toolName, glob string, ok bool) {
 	}
 	return rule[:open], rule[open+1 : len(rule)-1], true
 }
-
-// splitShellCommand splits a shell command string on the operators &&, ||, ;,
-// and | (single-pipe), returning each subcommand. This is intentionally naive
-// — it does not respect quoting or escapes — and follows Claude Code's
-// "if any subcommand matches" rule for permission filtering.
-func splitShellCommand(cmd string) []string {
-	parts := []string{cmd}
-	for _, sep := range []string{"&&", "||", ";", "|"} {
-		var next []string
-		for _, p := range parts {
-			next = append(next, strings.Split(p, sep)...)
-		}
-		parts = next
-	}
-	return parts
-}
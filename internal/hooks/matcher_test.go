@@ -18,7 +18,7 @@ func TestEvaluatePermissionRule_Bash(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input); got != tc.want {
+			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input, "/repo"); got != tc.want {
 				t.Fatalf("got %v want %v", got, tc.want)
 			}
 		})
@@ -40,7 +40,7 @@ func TestEvaluatePermissionRule_FileTools(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input); got != tc.want {
+			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input, "/repo"); got != tc.want {
 				t.Fatalf("got %v want %v", got, tc.want)
 			}
 		})
@@ -50,8 +50,138 @@ func TestEvaluatePermissionRule_FileTools(t *testing.T) {
 func TestEvaluatePermissionRule_Malformed(t *testing.T) {
 	cases := []string{"", "Bash", "Bash(", "(foo)", "   "}
 	for _, rule := range cases {
-		if evaluatePermissionRule(rule, "Bash", map[string]any{"command": "ls"}) {
+		if evaluatePermissionRule(rule, "Bash", map[string]any{"command": "ls"}, "") {
 			t.Fatalf("rule %q should not match", rule)
 		}
 	}
 }
+
+func TestEvaluatePermissionRule_OtherTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		toolName string
+		input    map[string]any
+		want     bool
+	}{
+		{"webfetch domain", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "https://example.com/docs"}, true},
+		{"webfetch domain case-insensitive", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "https://EXAMPLE.com"}, true},
+		{"webfetch subdomain needs glob", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "https://api.example.com"}, false},
+		{"webfetch subdomain glob", "WebFetch(domain:*.example.com)", "WebFetch", map[string]any{"url": "https://api.example.com:8443/x"}, true},
+		{"webfetch without domain prefix", "WebFetch(example.com)", "WebFetch", map[string]any{"url": "https://example.com"}, false},
+		{"webfetch bad url", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "::"}, false},
+		{"glob path", "Glob(/vendor/*)", "Glob", map[string]any{"pattern": "**/*.go", "path": "/repo/vendor/lib"}, true},
+		{"grep path basename", "Grep(secrets)", "Grep", map[string]any{"pattern": "token", "path": "/repo/secrets"}, true},
+		{"grep without path", "Grep(secrets)", "Grep", map[string]any{"pattern": "token"}, false},
+		{"star matches any call", "Grep(*)", "Grep", map[string]any{"pattern": "token"}, true},
+		{"notebook path", "NotebookEdit(*.ipynb)", "NotebookEdit", map[string]any{"notebook_path": "/repo/analysis.ipynb"}, true},
+		{"agent subagent type", "Agent(Explore)", "Agent", map[string]any{"subagent_type": "Explore"}, true},
+		{"agent rule matches legacy Task tool", "Agent(Explore)", "Task", map[string]any{"subagent_type": "Explore"}, true},
+		{"agent other type", "Agent(Explore)", "Agent", map[string]any{"subagent_type": "general-purpose"}, false},
+		{"mcp server", "mcp__github", "mcp__github__get_issue", nil, true},
+		{"mcp server prefix is not a match", "mcp__git", "mcp__github__get_issue", nil, false},
+		{"mcp exact tool", "mcp__github__get_issue", "mcp__github__get_issue", nil, true},
+		{"mcp other tool", "mcp__github__get_issue", "mcp__github__create_issue", nil, false},
+		{"mcp tool glob", "mcp__github__create_*", "mcp__github__create_issue", nil, true},
+		{"mcp rule vs builtin tool", "mcp__github", "Bash", map[string]any{"command": "ls"}, false},
+		{"unsupported tool", "WebSearch(foo)", "WebSearch", map[string]any{"query": "foo"}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input, "/repo"); got != tc.want {
+				t.Fatalf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestEvaluatePermissionRule_Globs mirrors the rule behaviors documented for
+// Claude Code's permission settings. The working dir, and project root, is
+// /repo.
+func TestEvaluatePermissionRule_Globs(t *testing.T) {
+	bash := func(cmd string) map[string]any { return map[string]any{"command": cmd} }
+	file := func(path string) map[string]any { return map[string]any{"file_path": path} }
+
+	tests := []struct {
+		name     string
+		rule     string
+		toolName string
+		input    map[string]any
+		want     bool
+	}{
+		// Bash: wildcards cross '/', trailing " *" and ":*" cover the bare command.
+		{"bash star crosses slash", "Bash(rm -rf *)", "Bash", bash("rm -rf /tmp/build"), true},
+		{"bash trailing star matches bare command", "Bash(git push --force *)", "Bash", bash("git push --force"), true},
+		{"bash space before star is a word boundary", "Bash(ls *)", "Bash", bash("lsof -i"), false},
+		{"bash no space matches any suffix", "Bash(ls*)", "Bash", bash("lsof -i"), true},
+		{"bash legacy prefix syntax", "Bash(npm run test:*)", "Bash", bash("npm run test -- --watch"), true},
+		{"bash legacy prefix bare", "Bash(npm run test:*)", "Bash", bash("npm run test"), true},
+		{"bash exact", "Bash(npm test)", "Bash", bash("npm test --watch"), false},
+		{"bash braces", "Bash(git {push,pull} *)", "Bash", bash("git pull origin"), true},
+
+		// File tools: gitignore-style doublestar matching.
+		{"doublestar relative", "Edit(src/**/*.go)", "Edit", file("/repo/src/pkg/a.go"), true},
+		{"doublestar zero dirs", "Edit(src/**/*.go)", "Edit", file("/repo/src/a.go"), true},
+		{"doublestar other tree", "Edit(src/**/*.go)", "Edit", file("/repo/lib/a.go"), false},
+		{"single star stays in directory", "Edit(src/*.go)", "Edit", file("/repo/src/pkg/a.go"), false},
+		{"segment boundary respected", "Edit(src/*.go)", "Edit", file("/repo/xsrc/a.go"), false},
+		{"double slash is absolute", "Read(//etc/**)", "Read", file("/etc/ssl/cert.pem"), true},
+		{"double slash no suffix match", "Read(//etc/**)", "Read", file("/repo/etc/x"), false},
+		{"single slash is project-relative", "Read(/etc/**)", "Read", file("/repo/etc/x"), true},
+		{"single slash is not absolute", "Read(/etc/**)", "Read", file("/etc/passwd"), false},
+		{"dot slash is working-dir relative", "Read(./.env)", "Read", file("/repo/.env"), true},
+		{"dot slash is anchored", "Read(./.env)", "Read", file("/repo/sub/.env"), false},
+		{"dot slash doublestar", "Read(./secrets/**)", "Read", file("/repo/secrets/prod/key.pem"), true},
+		{"dot slash doublestar other tree", "Read(./secrets/**)", "Read", file("/other/secrets/key.pem"), false},
+		{"relative path resolved against working dir", "Read(./.env)", "Read", file(".env"), true},
+		{"brace alternatives", "Write({*.yaml,*.yml})", "Write", file("/repo/ci.yml"), true},
+		{"brace alternatives miss", "Write({*.yaml,*.yml})", "Write", file("/repo/ci.json"), false},
+
+		// Negation.
+		{"negated glob excludes", "Edit(!*.md)", "Edit", file("/repo/README.md"), false},
+		{"negated glob includes others", "Edit(!*.md)", "Edit", file("/repo/main.go"), true},
+		{"negation needs the field", "Edit(!*.md)", "Edit", map[string]any{}, false},
+		{"negation needs the tool", "Edit(!*.md)", "Write", file("/repo/main.go"), false},
+		{"negated bash", "Bash(!git *)", "Bash", bash("ls && git status"), false},
+
+		// Composites.
+		{"all_of with exclusion", "all_of(Edit(src/**), Edit(!src/generated/**))", "Edit", file("/repo/src/a.go"), true},
+		{"all_of exclusion hits", "all_of(Edit(src/**), Edit(!src/generated/**))", "Edit", file("/repo/src/generated/a.go"), false},
+		{"any_of across tools", "any_of(Write({*.yaml,*.yml}), mcp__github)", "mcp__github__get_issue", nil, true},
+		{"any_of none", "any_of(Edit(*.go), Write(*.go))", "Read", file("a.go"), false},
+		{"nested composites", "any_of(all_of(Bash(go *), Bash(*-race*)), Edit(*.go))", "Bash", bash("go test -race ./..."), true},
+		{"empty all_of", "all_of()", "Bash", bash("ls"), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evaluatePermissionRule(tc.rule, tc.toolName, tc.input, "/repo"); got != tc.want {
+				t.Fatalf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePermissionRule_ShellAware(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		input string
+		want  bool
+	}{
+		{"bash -c wrapper", "Bash(rm -rf *)", `cd x && bash -c "rm -rf y"`, true},
+		{"sudo prefix", "Bash(rm -rf *)", "sudo rm -rf /var/cache", true},
+		{"command substitution", "Bash(curl *)", "echo $(curl -s https://x.test)", true},
+		{"xargs", "Bash(rm *)", "ls | xargs rm", true},
+		{"quoted operator is not a split", "Bash(rm *)", `echo "done; rm me"`, false},
+		{"heredoc body to cat is data", "Bash(rm *)", "cat <<EOF\nrm -rf /\nEOF", false},
+		{"assignment prefix", "Bash(go test *)", "GOFLAGS=-mod=mod go test ./...", true},
+		{"quotes removed for matching", "Bash(git commit -m fix*)", `git commit -m "fix: thing"`, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := evaluatePermissionRule(tc.rule, "Bash", map[string]any{"command": tc.input}, ""); got != tc.want {
+				t.Fatalf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
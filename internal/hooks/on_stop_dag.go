@@ -0,0 +1,222 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grovetools/core/config"
+)
+
+// on_stop ordering: hooks run in parallel unless an entry lists
+// prerequisites by name, and [hooks] max_parallel caps how many run at once.
+//
+//	[hooks]
+//	max_parallel = 2
+//
+//	[[hooks.on_stop]]
+//	name = "build"
+//	command = "go generate ./... && go build ./..."
+//
+//	[[hooks.on_stop]]
+//	name = "lint"
+//	command = "golangci-lint run"
+//	needs = ["build"]
+//
+// A hook starts once every hook it needs has passed or was skipped by its
+// own gating (disabled, env, run_if conditions). When a prerequisite fails,
+// times out, or was itself skipped for a failed prerequisite, the dependent
+// is skipped and its .summary records why ("skipped: needs build (failed)").
+// Unknown names and dependency cycles also skip the hooks involved.
+
+// OnStopHook is one [[hooks.on_stop]] entry: core's HookCommand plus the
+// options this package adds. It is decoded locally from the hooks extension.
+type OnStopHook struct {
+	config.HookCommand `yaml:",squash"`
+
+	Needs []string `yaml:"needs"`
+
+	// Conditions beyond run_if; see run_if.go.
+	ChangedPaths []string `yaml:"changed_paths"`
+	Branches     []string `yaml:"branches"`
+	SessionTypes []string `yaml:"session_types"`
+	Providers    []string `yaml:"providers"`
+	ExitReasons  []string `yaml:"exit_reasons"`
+	RunIfCommand string   `yaml:"run_if_command"`
+
+	// Result caching; see cache.go.
+	Cache       bool     `yaml:"cache"`
+	CacheInputs []string `yaml:"cache_inputs"`
+
+	// Failure output parsing; see output_parsers.go.
+	Parser     string `yaml:"parser"`
+	ReportFile string `yaml:"report_file"`
+
+	// Retries for flaky failures; see retry.go.
+	Retries          int   `yaml:"retries"`
+	RetryOnExitCodes []int `yaml:"retry_on_exit_codes"`
+
+	// Process control and limits; see process_group.go.
+	KillGrace   int    `yaml:"kill_grace"`
+	Nice        int    `yaml:"nice"`
+	MemoryLimit string `yaml:"memory_limit"`
+	CPULimit    int    `yaml:"cpu_limit"`
+}
+
+// OnStopConfig is the on_stop part of the hooks extension.
+type OnStopConfig struct {
+	OnStop      []OnStopHook `yaml:"on_stop"`
+	MaxParallel int          `yaml:"max_parallel"`
+}
+
+// satisfiesDependents reports whether hooks that need this one may run.
+func (r asyncHookResult) satisfiesDependents() bool {
+	return hookPassed(r.Status) || r.Gated
+}
+
+// executeAsyncHooks runs the on_stop hooks for stop in dependency order with
+// at most maxParallel running at once (unlimited when <= 0), and returns the
+// aggregated exit code (2 if any hook failed, otherwise 0). Failure output is
+// reported in config order; progress is tracked in run.json
+// (on_stop_status.go).
+func executeAsyncHooks(hooks []OnStopHook, maxParallel int, stop *onStopContext, stateDir string) int {
+	n := len(hooks)
+	if maxParallel <= 0 || maxParallel > n {
+		maxParallel = n
+	}
+	results := make([]asyncHookResult, n)
+	needs, invalid := resolveOnStopNeeds(hooks)
+	tracker := newOnStopTracker(hooks, stop, stateDir)
+	stop.RunID = tracker.run.RunID
+
+	summaryPath := func(i int) string {
+		return filepath.Join(stateDir, slugifyHookName(hooks[i].Name)+".summary")
+	}
+
+	// The scheduler loop owns all state; workers only report completion.
+	const (
+		pending = iota
+		running
+		done
+	)
+	state := make([]int, n)
+	finished := make(chan int)
+	active := 0
+	for {
+		// Settle skips and starts until nothing changes, since a skip can
+		// unblock (and skip) its dependents in turn.
+		for changed := true; changed; {
+			changed = false
+			for i := range hooks {
+				if state[i] != pending {
+					continue
+				}
+				if invalid[i] != "" {
+					results[i] = skippedHook(summaryPath(i), invalid[i])
+					state[i] = done
+					tracker.finish(i, results[i].Status)
+					changed = true
+					continue
+				}
+				ready, failedDep := true, -1
+				for _, j := range needs[i] {
+					if state[j] != done {
+						ready = false
+						continue
+					}
+					if !results[j].satisfiesDependents() && failedDep < 0 {
+						failedDep = j
+					}
+				}
+				if failedDep >= 0 {
+					outcome, _, _ := strings.Cut(results[failedDep].Status, ":")
+					if outcome == "" {
+						outcome = "not run"
+					}
+					results[i] = skippedHook(summaryPath(i), fmt.Sprintf("needs %s (%s)", hooks[failedDep].Name, outcome))
+					state[i] = done
+					tracker.finish(i, results[i].Status)
+					changed = true
+					continue
+				}
+				if !ready || active >= maxParallel {
+					continue
+				}
+				state[i] = running
+				tracker.start(i)
+				active++
+				changed = true
+				go func(i int) {
+					results[i] = runSingleAsyncHook(hooks[i], stop, stateDir)
+					finished <- i
+				}(i)
+			}
+		}
+
+		if active == 0 {
+			break
+		}
+		i := <-finished
+		state[i] = done
+		tracker.finish(i, results[i].Status)
+		active--
+	}
+
+	// Anything still pending waits on itself through a cycle.
+	for i := range hooks {
+		if state[i] == pending {
+			results[i] = skippedHook(summaryPath(i), "dependency cycle")
+			tracker.finish(i, results[i].Status)
+		}
+	}
+	tracker.close()
+
+	var blockingErrs []string
+	for i, r := range results {
+		if !r.Blocking {
+			continue
+		}
+		stderr := r.Stderr
+		if stderr == "" {
+			stderr = fmt.Sprintf("hook %q exited 2", hooks[i].Name)
+		}
+		blockingErrs = append(blockingErrs, stderr)
+	}
+	if len(blockingErrs) > 0 {
+		fmt.Fprint(os.Stderr, strings.Join(blockingErrs, "\n\n"))
+		if !strings.HasSuffix(blockingErrs[len(blockingErrs)-1], "\n") {
+			fmt.Fprintln(os.Stderr)
+		}
+		return 2
+	}
+	return 0
+}
+
+// resolveOnStopNeeds maps each hook's needs to hook indexes. invalid[i] is
+// the skip reason for a hook with an unknown or self dependency.
+func resolveOnStopNeeds(hooks []OnStopHook) (needs [][]int, invalid []string) {
+	index := make(map[string]int, len(hooks))
+	for i, h := range hooks {
+		if _, ok := index[h.Name]; !ok && h.Name != "" {
+			index[h.Name] = i
+		}
+	}
+	needs = make([][]int, len(hooks))
+	invalid = make([]string, len(hooks))
+	for i, h := range hooks {
+		for _, name := range h.Needs {
+			j, ok := index[name]
+			if !ok {
+				invalid[i] = fmt.Sprintf("needs unknown hook %q", name)
+				break
+			}
+			if j == i {
+				invalid[i] = "dependency cycle"
+				break
+			}
+			needs[i] = append(needs[i], j)
+		}
+	}
+	return needs, invalid
+}
@@ -0,0 +1,133 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grovetools/core/config"
+)
+
+func onStopHook(name, command string, needs ...string) OnStopHook {
+	return OnStopHook{HookCommand: config.HookCommand{Name: name, Command: command}, Needs: needs}
+}
+
+func lastSummary(t *testing.T, stateDir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(stateDir, slugifyHookName(name)+".summary"))
+	if err != nil {
+		t.Fatalf("summary for %s: %v", name, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	_, status, _ := strings.Cut(lines[len(lines)-1], "] ")
+	return status
+}
+
+func TestExecuteAsyncHooksOrdersByNeeds(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+
+	hooks := []OnStopHook{
+		onStopHook("lint", "test -f generated", "build"),
+		onStopHook("test", "test -f generated", "build"),
+		onStopHook("build", "sleep 0.2 && touch generated"),
+	}
+	if code := executeAsyncHooks(hooks, 0, &onStopContext{WorkingDir: work}, stateDir); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	for _, name := range []string{"build", "lint", "test"} {
+		if got := lastSummary(t, stateDir, name); got != "passed" {
+			t.Errorf("%s summary = %q, want passed", name, got)
+		}
+	}
+}
+
+func TestExecuteAsyncHooksSkipsDependentsOfFailures(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	t.Setenv("GROVE_TEST_SKIP_HOOK", "1")
+	work, stateDir := t.TempDir(), t.TempDir()
+
+	gated := onStopHook("optional", "exit 1")
+	gated.DisableEnv = "GROVE_TEST_SKIP_HOOK"
+	hooks := []OnStopHook{
+		onStopHook("build", "exit 1"),
+		onStopHook("lint", "true", "build"),
+		onStopHook("report", "true", "lint"),
+		onStopHook("after-optional", "true", "optional"),
+		gated,
+		onStopHook("typo", "true", "biuld"),
+		onStopHook("a", "true", "b"),
+		onStopHook("b", "true", "a"),
+	}
+	if code := executeAsyncHooks(hooks, 2, &onStopContext{WorkingDir: work}, stateDir); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	for name, want := range map[string]string{
+		"build":          "failed",
+		"lint":           "skipped: needs build (failed)",
+		"report":         "skipped: needs lint (skipped)",
+		"optional":       "skipped: GROVE_TEST_SKIP_HOOK is set",
+		"after-optional": "passed",
+		"typo":           `skipped: needs unknown hook "biuld"`,
+		"a":              "skipped: dependency cycle",
+		"b":              "skipped: dependency cycle",
+	} {
+		if got := lastSummary(t, stateDir, name); got != want {
+			t.Errorf("%s summary = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExecuteAsyncHooksMaxParallel(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+
+	// Each hook holds a mkdir lock; any overlap makes one of them fail.
+	exclusive := "mkdir lock && sleep 0.1 && rmdir lock"
+	hooks := []OnStopHook{
+		onStopHook("one", exclusive),
+		onStopHook("two", exclusive),
+		onStopHook("three", exclusive),
+	}
+	if code := executeAsyncHooks(hooks, 1, &onStopContext{WorkingDir: work}, stateDir); code != 0 {
+		t.Fatalf("hooks overlapped with max_parallel = 1 (exit %d)", code)
+	}
+}
+
+func TestOnStopConfigDecodesNeeds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	repo := t.TempDir()
+	toml := `[hooks]
+max_parallel = 2
+
+[[hooks.on_stop]]
+name = "lint"
+command = "golangci-lint run"
+run_if = "changes"
+needs = ["build"]
+`
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFrom(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got OnStopConfig
+	if err := cfg.UnmarshalExtension("hooks", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxParallel != 2 || len(got.OnStop) != 1 {
+		t.Fatalf("config = %+v", got)
+	}
+	h := got.OnStop[0]
+	if h.Name != "lint" || h.Command != "golangci-lint run" || h.RunIf != "changes" || len(h.Needs) != 1 || h.Needs[0] != "build" {
+		t.Fatalf("hook = %+v", h)
+	}
+}
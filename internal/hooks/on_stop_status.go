@@ -0,0 +1,307 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grovetools/core/pkg/models"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/grovetools/core/pkg/process"
+)
+
+// on_stop run status: while stop-async runs, the scheduler keeps
+// on_stop/run.json current with every hook's state (pending, running, done)
+// and publishes each transition to the daemon as a workflow agent event, so
+// the session browser can show "lint ⏳ 42s / tests ✓". The events carry
+// AgentType OnStopAgentType, the hook name as Name and, on completion, the
+// summary status as LastMessage. `grove hooks on-stop status` reads the same
+// file and the hooks' logs.
+
+// OnStopAgentType is the workflow AgentType of on_stop hook events.
+const OnStopAgentType = "on_stop_hook"
+
+// on_stop hook states in run.json.
+const (
+	OnStopPending = "pending"
+	OnStopRunning = "running"
+	OnStopDone    = "done"
+)
+
+// OnStopRun is one stop-async run, as recorded in on_stop/run.json.
+type OnStopRun struct {
+	RunID      string            `json:"run_id"`
+	PID        int               `json:"pid"`
+	WorkingDir string            `json:"working_dir"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Hooks      []OnStopHookState `json:"hooks"`
+
+	// StateDir is the on_stop directory the run was read from.
+	StateDir string `json:"-"`
+}
+
+// OnStopHookState is one hook's progress within a run. Status is the line
+// recorded in its .summary once it is done.
+type OnStopHookState struct {
+	Name       string    `json:"name"`
+	State      string    `json:"state"`
+	Status     string    `json:"status,omitempty"`
+	Timeout    int       `json:"timeout"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// OnStopStateDir returns the on_stop artifact directory for a session id.
+func OnStopStateDir(sessionID string) string {
+	return filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID, "on_stop")
+}
+
+// ReadOnStopRun reads the run.json in an on_stop state dir.
+func ReadOnStopRun(stateDir string) (*OnStopRun, error) {
+	content, err := os.ReadFile(filepath.Join(stateDir, "run.json"))
+	if err != nil {
+		return nil, err
+	}
+	var run OnStopRun
+	if err := json.Unmarshal(content, &run); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(stateDir, "run.json"), err)
+	}
+	run.StateDir = stateDir
+	return &run, nil
+}
+
+// OnStopRunForSession returns the on_stop run of a session, given either its
+// provider session id or its grove session id.
+func OnStopRunForSession(sessionID string) (*OnStopRun, error) {
+	nativeID, _ := resolveNativeSessionID(sessionID)
+	run, err := ReadOnStopRun(OnStopStateDir(nativeID))
+	if err != nil {
+		return nil, fmt.Errorf("no on_stop run recorded for session %s", sessionID)
+	}
+	return run, nil
+}
+
+// resolveNativeSessionID maps a grove session id to the provider session id
+// its state dir is keyed on. Other ids are returned unchanged; found is false
+// when no session dir or metadata matches the id.
+func resolveNativeSessionID(sessionID string) (nativeID string, found bool) {
+	sessionsDir := filepath.Join(paths.StateDir(), "hooks", "sessions")
+	if _, err := os.Stat(filepath.Join(sessionsDir, sessionID)); err == nil {
+		return sessionID, true
+	}
+	matches, _ := filepath.Glob(filepath.Join(sessionsDir, "*", "metadata.json"))
+	for _, match := range matches {
+		content, err := os.ReadFile(match)
+		if err != nil {
+			continue
+		}
+		var metadata struct {
+			SessionID string `json:"session_id"`
+		}
+		if json.Unmarshal(content, &metadata) == nil && metadata.SessionID == sessionID {
+			return filepath.Base(filepath.Dir(match)), true
+		}
+	}
+	return sessionID, false
+}
+
+// ResolveSessionID returns the provider session id a provider or grove
+// session id refers to, or an error when no session matches it.
+func ResolveSessionID(sessionID string) (string, error) {
+	nativeID, found := resolveNativeSessionID(sessionID)
+	if !found {
+		return "", fmt.Errorf("unknown session %q: no session dir or metadata under %s", sessionID, filepath.Join(paths.StateDir(), "hooks", "sessions"))
+	}
+	return nativeID, nil
+}
+
+// LatestOnStopRun returns the most recently started on_stop run across all
+// sessions.
+func LatestOnStopRun() (*OnStopRun, error) {
+	matches, err := filepath.Glob(filepath.Join(paths.StateDir(), "hooks", "sessions", "*", "on_stop", "run.json"))
+	if err != nil {
+		return nil, err
+	}
+	var latest *OnStopRun
+	for _, match := range matches {
+		run, err := ReadOnStopRun(filepath.Dir(match))
+		if err != nil {
+			continue
+		}
+		if latest == nil || run.StartedAt.After(latest.StartedAt) {
+			latest = run
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no on_stop runs recorded under %s", filepath.Join(paths.StateDir(), "hooks", "sessions"))
+	}
+	return latest, nil
+}
+
+// Finished reports whether the run is over: it completed, or the stop-async
+// process that owned it is gone.
+func (r *OnStopRun) Finished() bool {
+	return !r.FinishedAt.IsZero() || !process.IsProcessAlive(r.PID)
+}
+
+// Interrupted reports whether stop-async exited without finishing the run.
+func (r *OnStopRun) Interrupted() bool {
+	return r.FinishedAt.IsZero() && !process.IsProcessAlive(r.PID)
+}
+
+// ExitCode is the run's aggregate result as stop-async reports it: 2 when a
+// hook failed or timed out, otherwise 0.
+func (r *OnStopRun) ExitCode() int {
+	for _, h := range r.Hooks {
+		if h.Failed() {
+			return 2
+		}
+	}
+	return 0
+}
+
+// Failed reports whether the hook is done and failed or timed out.
+func (h OnStopHookState) Failed() bool {
+	if h.State != OnStopDone || strings.HasPrefix(h.Status, "skipped") {
+		return false
+	}
+	return !hookPassed(strings.TrimSuffix(h.Status, " (cached)"))
+}
+
+// Slug is the hook's artifact filename stem in the state dir.
+func (h OnStopHookState) Slug() string {
+	return slugifyHookName(h.Name)
+}
+
+// Elapsed is how long the hook has run, or ran. For a running hook it is
+// measured from its .pid file, which each attempt rewrites, so it is
+// comparable to the per-attempt timeout.
+func (h OnStopHookState) Elapsed(stateDir string, now time.Time) time.Duration {
+	switch h.State {
+	case OnStopRunning:
+		start := h.StartedAt
+		if info, err := os.Stat(filepath.Join(stateDir, h.Slug()+".pid")); err == nil {
+			start = info.ModTime()
+		}
+		return now.Sub(start)
+	case OnStopDone:
+		if !h.StartedAt.IsZero() {
+			return h.FinishedAt.Sub(h.StartedAt)
+		}
+	}
+	return 0
+}
+
+// hookTimeout is the hook's per-attempt timeout in seconds.
+func (hc OnStopHook) hookTimeout() int {
+	if hc.Timeout > 0 {
+		return hc.Timeout
+	}
+	return defaultAsyncHookTimeout
+}
+
+// onStopTracker records the scheduler's state transitions in run.json and
+// queues them for the daemon. It is used only from the scheduler goroutine.
+type onStopTracker struct {
+	path string
+	run  OnStopRun
+	stop *onStopContext
+
+	events chan models.WorkflowEvent
+	sent   chan struct{}
+}
+
+// newOnStopTracker starts a run with every hook pending.
+func newOnStopTracker(hooks []OnStopHook, stop *onStopContext, stateDir string) *onStopTracker {
+	now := time.Now().UTC()
+	t := &onStopTracker{
+		path: filepath.Join(stateDir, "run.json"),
+		run: OnStopRun{
+			RunID:      "on_stop_" + now.Format("20060102T150405.000Z"),
+			PID:        os.Getpid(),
+			WorkingDir: stop.WorkingDir,
+			StartedAt:  now,
+			Hooks:      make([]OnStopHookState, len(hooks)),
+		},
+		stop: stop,
+		// Each hook sends at most a start and a completion.
+		events: make(chan models.WorkflowEvent, 2*len(hooks)),
+		sent:   make(chan struct{}),
+	}
+	for i, hc := range hooks {
+		t.run.Hooks[i] = OnStopHookState{Name: hc.Name, State: OnStopPending, Timeout: hc.hookTimeout()}
+	}
+	t.write()
+
+	// Publish in order on one goroutine so a slow daemon cannot hold up the
+	// scheduler and a completion never overtakes its start.
+	go func() {
+		defer close(t.sent)
+		for ev := range t.events {
+			forwardWorkflowEvent(stop.Daemon, stop.WorkingDir, ev)
+		}
+	}()
+	return t
+}
+
+// start marks hook i running.
+func (t *onStopTracker) start(i int) {
+	h := &t.run.Hooks[i]
+	h.State = OnStopRunning
+	h.StartedAt = time.Now().UTC()
+	t.write()
+	t.publish(models.WorkflowAgentStarted, *h)
+}
+
+// finish marks hook i done with its summary status.
+func (t *onStopTracker) finish(i int, status string) {
+	h := &t.run.Hooks[i]
+	h.State = OnStopDone
+	h.Status = status
+	h.FinishedAt = time.Now().UTC()
+	t.write()
+	t.publish(models.WorkflowAgentCompleted, *h)
+}
+
+// close marks the run finished and waits for queued events to be sent.
+func (t *onStopTracker) close() {
+	t.run.FinishedAt = time.Now().UTC()
+	t.write()
+	close(t.events)
+	<-t.sent
+}
+
+func (t *onStopTracker) publish(kind models.WorkflowEventKind, h OnStopHookState) {
+	if t.stop.Daemon == nil {
+		return
+	}
+	t.events <- models.WorkflowEvent{
+		Kind:            kind,
+		JobID:           os.Getenv("GROVE_FLOW_JOB_ID"),
+		ClaudeSessionID: t.stop.NativeSessionID,
+		AgentID:         t.run.RunID + ":" + h.Slug(),
+		AgentType:       OnStopAgentType,
+		Name:            h.Name,
+		RunID:           t.run.RunID,
+		LastMessage:     h.Status,
+		Timestamp:       time.Now(),
+		Source:          models.WorkflowSourceHooks,
+	}
+}
+
+// write replaces run.json, via a rename so readers never see a partial file.
+func (t *onStopTracker) write() {
+	content, err := json.MarshalIndent(t.run, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, t.path)
+}
@@ -0,0 +1,133 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/daemon"
+	"github.com/grovetools/core/pkg/models"
+)
+
+// recordingDaemon captures published workflow events.
+type recordingDaemon struct {
+	daemon.Client
+
+	mu     sync.Mutex
+	events []models.WorkflowEvent
+}
+
+func (d *recordingDaemon) PublishWorkflowEvent(_ context.Context, ev models.WorkflowEvent) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, ev)
+	return nil
+}
+
+func TestExecuteAsyncHooksRecordsRunState(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+
+	recorder := &recordingDaemon{}
+	stop := &onStopContext{WorkingDir: work, NativeSessionID: "native-1", Daemon: recorder}
+	slow := onStopHook("lint", "exit 1")
+	slow.Timeout = 30
+	hooks := []OnStopHook{onStopHook("test", "true"), slow, onStopHook("report", "true", "lint")}
+	if code := executeAsyncHooks(hooks, 0, stop, stateDir); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+
+	run, err := ReadOnStopRun(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.FinishedAt.IsZero() || !run.Finished() || run.Interrupted() {
+		t.Errorf("run not recorded as finished: %+v", run)
+	}
+	if run.ExitCode() != 2 {
+		t.Errorf("ExitCode = %d, want 2", run.ExitCode())
+	}
+	want := map[string]string{"test": "passed", "lint": "failed", "report": "skipped: needs lint (failed)"}
+	for _, h := range run.Hooks {
+		if h.State != OnStopDone || h.Status != want[h.Name] {
+			t.Errorf("%s: state %s status %q, want done %q", h.Name, h.State, h.Status, want[h.Name])
+		}
+	}
+	if run.Hooks[1].Timeout != 30 || run.Hooks[0].Timeout != defaultAsyncHookTimeout {
+		t.Errorf("timeouts = %d, %d", run.Hooks[0].Timeout, run.Hooks[1].Timeout)
+	}
+
+	// lint and test start and complete; report only completes.
+	var lint []models.WorkflowEvent
+	for _, ev := range recorder.events {
+		if ev.AgentType != OnStopAgentType || ev.ClaudeSessionID != "native-1" || ev.RunID != run.RunID {
+			t.Errorf("unexpected event keying: %+v", ev)
+		}
+		if ev.Name == "lint" {
+			lint = append(lint, ev)
+		}
+	}
+	if len(recorder.events) != 5 {
+		t.Errorf("published %d events, want 5", len(recorder.events))
+	}
+	if len(lint) != 2 || lint[0].Kind != models.WorkflowAgentStarted || lint[1].Kind != models.WorkflowAgentCompleted || lint[1].LastMessage != "failed" {
+		t.Errorf("lint events = %+v", lint)
+	}
+}
+
+func TestOnStopHookElapsedUsesPidFile(t *testing.T) {
+	stateDir := t.TempDir()
+	now := time.Now()
+	h := OnStopHookState{Name: "lint", State: OnStopRunning, StartedAt: now.Add(-time.Minute)}
+	if got := h.Elapsed(stateDir, now).Round(time.Second); got != time.Minute {
+		t.Errorf("elapsed without pid file = %s, want 1m0s", got)
+	}
+
+	// A retry rewrites the pid file, restarting the attempt's clock.
+	pidPath := filepath.Join(stateDir, "lint.pid")
+	if err := os.WriteFile(pidPath, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(pidPath, now.Add(-10*time.Second), now.Add(-10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Elapsed(stateDir, now).Round(time.Second); got != 10*time.Second {
+		t.Errorf("elapsed = %s, want 10s", got)
+	}
+}
+
+func TestOnStopRunForSessionResolvesGroveID(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	stateDir := OnStopStateDir("native-1")
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stop := &onStopContext{WorkingDir: t.TempDir()}
+	executeAsyncHooks([]OnStopHook{onStopHook("test", "true")}, 0, stop, stateDir)
+	metadata := filepath.Join(filepath.Dir(stateDir), "metadata.json")
+	if err := os.WriteFile(metadata, []byte(`{"session_id":"grove-1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"native-1", "grove-1"} {
+		run, err := OnStopRunForSession(id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if run.StateDir != stateDir {
+			t.Errorf("%s: state dir = %s, want %s", id, run.StateDir, stateDir)
+		}
+	}
+	if _, err := OnStopRunForSession("missing"); err == nil {
+		t.Error("expected an error for an unknown session")
+	}
+	if latest, err := LatestOnStopRun(); err != nil || latest.StateDir != stateDir {
+		t.Errorf("LatestOnStopRun = %v, %v", latest, err)
+	}
+}
@@ -0,0 +1,430 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// on_stop output parsers: a hook can name the format its tool emits, and a
+// failure is then reported to the agent as a compact file:line: message list
+// instead of the raw stderr.
+//
+//	[[hooks.on_stop]]
+//	name = "lint"
+//	command = "golangci-lint run --out-format json"
+//	parser = "golangci-lint"   # go-test-json, golangci-lint, sarif, tap, junit
+//	report_file = ""           # read the report from this file instead of stdout
+//
+// go-test-json reads `go test -json` events (and compiler errors on stderr);
+// golangci-lint reads its JSON output; sarif, tap and junit read SARIF 2.1,
+// TAP and JUnit XML. report_file is relative to the working directory and is
+// deleted before each attempt, so only a report the attempt wrote is read.
+// Every parsed run is saved as <slug>.findings.json next to the .log. When a
+// report cannot be parsed, or a failure yields no findings, the raw stderr is
+// used as before.
+
+// maxReportedFindings caps the findings listed in a rewake message; the full
+// list stays in the .findings.json file.
+const maxReportedFindings = 30
+
+// hookFinding is one problem reported by a parsed tool.
+type hookFinding struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String renders the finding as file:line:col: [rule] message.
+func (f hookFinding) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&b, ":%d", f.Line)
+			if f.Column > 0 {
+				fmt.Fprintf(&b, ":%d", f.Column)
+			}
+		}
+		b.WriteString(": ")
+	}
+	if f.Rule != "" {
+		fmt.Fprintf(&b, "%s: ", f.Rule)
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// hookFindings is the <slug>.findings.json document.
+type hookFindings struct {
+	Parser     string        `json:"parser"`
+	Status     string        `json:"status"`
+	Findings   []hookFinding `json:"findings"`
+	ParseError string        `json:"parse_error,omitempty"`
+}
+
+// outputParsers maps parser names to implementations. Each gets the report
+// (stdout or report_file) and the hook's stderr.
+var outputParsers = map[string]func(report, stderr []byte) ([]hookFinding, error){
+	"go-test-json":  parseGoTestJSON,
+	"golangci-lint": parseGolangciLint,
+	"sarif":         parseSARIF,
+	"tap":           parseTAP,
+	"junit":         parseJUnit,
+}
+
+// reportPath resolves the hook's report_file against workingDir.
+func (hc OnStopHook) reportPath(workingDir string) string {
+	if filepath.IsAbs(hc.ReportFile) {
+		return hc.ReportFile
+	}
+	return filepath.Join(workingDir, hc.ReportFile)
+}
+
+// parseHookOutput runs the hook's parser. The report is read from
+// report_file when set, else stdout.
+func parseHookOutput(hc OnStopHook, workingDir string, stdout, stderr []byte) ([]hookFinding, error) {
+	parse, ok := outputParsers[hc.Parser]
+	if !ok {
+		return nil, fmt.Errorf("unknown parser %q", hc.Parser)
+	}
+	report := stdout
+	if hc.ReportFile != "" {
+		content, err := os.ReadFile(hc.reportPath(workingDir))
+		if err != nil {
+			return nil, fmt.Errorf("read report_file: %w", err)
+		}
+		report = content
+	}
+	return parse(report, stderr)
+}
+
+// writeHookFindings saves the parsed result next to the hook's log.
+func writeHookFindings(path string, doc hookFindings) {
+	if doc.Findings == nil {
+		doc.Findings = []hookFinding{}
+	}
+	if content, err := json.MarshalIndent(doc, "", "  "); err == nil {
+		_ = os.WriteFile(path, content, 0o644)
+	}
+}
+
+// formatHookFindings renders findings for a rewake message.
+func formatHookFindings(header, parser string, findings []hookFinding, logPath string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d finding(s) from %s", header, len(findings), parser)
+	for i, f := range findings {
+		if i == maxReportedFindings {
+			fmt.Fprintf(&b, "\n… and %d more", len(findings)-maxReportedFindings)
+			break
+		}
+		b.WriteString("\n")
+		b.WriteString(f.String())
+	}
+	fmt.Fprintf(&b, "\nfull log: %s", logPath)
+	return b.String()
+}
+
+// jsonStart returns data from its first '{', skipping any preamble.
+func jsonStart(data []byte) []byte {
+	if i := bytes.IndexByte(data, '{'); i >= 0 {
+		return data[i:]
+	}
+	return data
+}
+
+var (
+	// goTestLocation matches t.Error output such as "    foo_test.go:12: msg".
+	goTestLocation = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): (.*)$`)
+	// goCompileError matches compiler output such as "pkg/a.go:3:2: undefined: x".
+	goCompileError = regexp.MustCompile(`^([^\s:]+\.go):(\d+):(\d+): (.*)$`)
+)
+
+// parseGoTestJSON reports failed tests from `go test -json` events, located
+// at their first t.Error line, plus compiler errors.
+func parseGoTestJSON(report, stderr []byte) ([]hookFinding, error) {
+	type event struct {
+		Action  string
+		Package string
+		Test    string
+		Output  string
+	}
+	output := map[string][]string{}
+	var findings []hookFinding
+	seen := map[string]bool{}
+	addCompileErrors := func(lines []string) {
+		for _, line := range lines {
+			m := goCompileError.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil || seen[m[0]] {
+				continue
+			}
+			seen[m[0]] = true
+			lineNo, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			findings = append(findings, hookFinding{File: m[1], Line: lineNo, Column: col, Rule: "build", Message: m[4]})
+		}
+	}
+
+	events := 0
+	scanner := bufio.NewScanner(bytes.NewReader(report))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e event
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Action == "" {
+			continue
+		}
+		events++
+		key := e.Package + "\x00" + e.Test
+		switch e.Action {
+		case "output", "build-output":
+			output[key] = append(output[key], strings.TrimRight(e.Output, "\n"))
+		case "fail":
+			if e.Test == "" {
+				addCompileErrors(output[key])
+				continue
+			}
+			f := hookFinding{Rule: e.Test, Message: "test failed"}
+			for _, line := range output[key] {
+				if m := goTestLocation.FindStringSubmatch(line); m != nil {
+					f.File = m[1]
+					f.Line, _ = strconv.Atoi(m[2])
+					f.Message = m[3]
+					break
+				}
+			}
+			if e.Package != "" {
+				f.Message += " (" + e.Package + ")"
+			}
+			findings = append(findings, f)
+		}
+	}
+	addCompileErrors(strings.Split(string(stderr), "\n"))
+	if events == 0 && len(findings) == 0 {
+		return nil, errors.New("no go test -json events in output")
+	}
+	return findings, nil
+}
+
+// parseGolangciLint reads golangci-lint's JSON report.
+func parseGolangciLint(report, _ []byte) ([]hookFinding, error) {
+	var doc struct {
+		Issues []struct {
+			FromLinter string
+			Text       string
+			Severity   string
+			Pos        struct {
+				Filename string
+				Line     int
+				Column   int
+			}
+		}
+	}
+	if err := json.NewDecoder(bytes.NewReader(jsonStart(report))).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode golangci-lint JSON: %w", err)
+	}
+	findings := make([]hookFinding, 0, len(doc.Issues))
+	for _, issue := range doc.Issues {
+		findings = append(findings, hookFinding{
+			File:     issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Rule:     issue.FromLinter,
+			Severity: issue.Severity,
+			Message:  issue.Text,
+		})
+	}
+	return findings, nil
+}
+
+// parseSARIF reads the results of every run in a SARIF 2.1 log.
+func parseSARIF(report, _ []byte) ([]hookFinding, error) {
+	var doc struct {
+		Runs []struct {
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(jsonStart(report))).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode SARIF: %w", err)
+	}
+	var findings []hookFinding
+	for _, run := range doc.Runs {
+		for _, r := range run.Results {
+			f := hookFinding{Rule: r.RuleID, Severity: r.Level, Message: r.Message.Text}
+			if len(r.Locations) > 0 {
+				loc := r.Locations[0].PhysicalLocation
+				f.File = strings.TrimPrefix(loc.ArtifactLocation.URI, "file://")
+				f.Line = loc.Region.StartLine
+				f.Column = loc.Region.StartColumn
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// tapAt matches a TAP YAML "at:" location such as "test/a.js:12:3".
+var tapAt = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?$`)
+
+// parseTAP reports "not ok" points, excluding TODO and SKIP, with the
+// message and location from their YAML diagnostic block.
+func parseTAP(report, _ []byte) ([]hookFinding, error) {
+	var findings []hookFinding
+	var current *hookFinding
+	inYAML, sawPlan := false, false
+	for _, line := range strings.Split(string(report), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inYAML:
+			if trimmed == "..." {
+				inYAML = false
+				continue
+			}
+			if current == nil {
+				continue
+			}
+			key, value, _ := strings.Cut(trimmed, ":")
+			value = unquoteYAML(value)
+			switch key {
+			case "message":
+				if value != "" {
+					current.Message = value
+				}
+			case "severity":
+				current.Severity = value
+			case "file":
+				current.File = value
+			case "line":
+				current.Line, _ = strconv.Atoi(value)
+			case "at":
+				if m := tapAt.FindStringSubmatch(value); m != nil {
+					current.File = m[1]
+					current.Line, _ = strconv.Atoi(m[2])
+					current.Column, _ = strconv.Atoi(m[3])
+				}
+			}
+		case trimmed == "---":
+			inYAML = true
+		case strings.HasPrefix(trimmed, "not ok"):
+			sawPlan = true
+			current = nil
+			desc := strings.TrimSpace(strings.TrimPrefix(trimmed, "not ok"))
+			desc, directive, _ := strings.Cut(desc, "#")
+			directive = strings.ToUpper(strings.TrimSpace(directive))
+			if strings.HasPrefix(directive, "TODO") || strings.HasPrefix(directive, "SKIP") {
+				continue
+			}
+			// Drop the test number and the optional "- " separator.
+			desc = strings.TrimLeft(strings.TrimSpace(desc), "0123456789")
+			desc = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(desc), "-"))
+			findings = append(findings, hookFinding{Rule: desc, Message: "not ok"})
+			current = &findings[len(findings)-1]
+		case strings.HasPrefix(trimmed, "ok"), strings.HasPrefix(trimmed, "1.."), strings.HasPrefix(trimmed, "TAP version"):
+			sawPlan = true
+			current = nil
+		}
+	}
+	if !sawPlan {
+		return nil, errors.New("no TAP test points in output")
+	}
+	return findings, nil
+}
+
+// parseJUnit reports <failure> and <error> elements of JUnit XML test cases.
+func parseJUnit(report, _ []byte) ([]hookFinding, error) {
+	start := bytes.IndexByte(report, '<')
+	if start < 0 {
+		return nil, errors.New("no JUnit XML in output")
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(report[start:]))
+	var findings []hookFinding
+	var testcase hookFinding
+	var failure *hookFinding
+	var text strings.Builder
+	sawSuite := false
+	attr := func(el xml.StartElement, name string) string {
+		for _, a := range el.Attr {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode JUnit XML: %w", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "testsuites", "testsuite":
+				sawSuite = true
+			case "testcase":
+				sawSuite = true
+				name := attr(el, "name")
+				if class := attr(el, "classname"); class != "" {
+					name = class + "." + name
+				}
+				line, _ := strconv.Atoi(attr(el, "line"))
+				testcase = hookFinding{File: attr(el, "file"), Line: line, Rule: name}
+			case "failure", "error":
+				f := testcase
+				f.Severity = el.Name.Local
+				f.Message = attr(el, "message")
+				failure = &f
+				text.Reset()
+			}
+		case xml.CharData:
+			if failure != nil {
+				text.Write(el)
+			}
+		case xml.EndElement:
+			if (el.Name.Local == "failure" || el.Name.Local == "error") && failure != nil {
+				if failure.Message == "" {
+					failure.Message, _, _ = strings.Cut(strings.TrimSpace(text.String()), "\n")
+				}
+				if failure.Message == "" {
+					failure.Message = el.Name.Local
+				}
+				findings = append(findings, *failure)
+				failure = nil
+			}
+		}
+	}
+	if !sawSuite {
+		return nil, errors.New("no JUnit test suites in output")
+	}
+	return findings, nil
+}
@@ -0,0 +1,129 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func findingStrings(findings []hookFinding) string {
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = f.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestOutputParsers(t *testing.T) {
+	tests := []struct {
+		parser string
+		report string
+		stderr string
+		want   string
+	}{
+		{
+			parser: "go-test-json",
+			report: `{"Action":"run","Package":"ex/a","Test":"TestAdd"}
+{"Action":"output","Package":"ex/a","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"ex/a","Test":"TestAdd","Output":"    add_test.go:9: got 3, want 4\n"}
+{"Action":"fail","Package":"ex/a","Test":"TestAdd"}
+{"Action":"pass","Package":"ex/a","Test":"TestSub"}
+{"Action":"fail","Package":"ex/a"}
+{"Action":"fail","Package":"ex/b","Test":"TestPanics"}
+`,
+			stderr: "# ex/c\nc/c.go:3:2: undefined: x\n",
+			want:   "add_test.go:9: TestAdd: got 3, want 4 (ex/a)\nTestPanics: test failed (ex/b)\nc/c.go:3:2: build: undefined: x",
+		},
+		{
+			parser: "golangci-lint",
+			report: `level=warning msg="noise"` + "\n" + `{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Severity":"","Pos":{"Filename":"main.go","Line":12,"Column":5}}],"Report":{}}`,
+			want:   "main.go:12:5: errcheck: Error return value is not checked",
+		},
+		{
+			parser: "sarif",
+			report: `{"version":"2.1.0","runs":[{"results":[{"ruleId":"G104","level":"warning","message":{"text":"Errors unhandled"},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"file://cmd/x.go"},"region":{"startLine":7,"startColumn":2}}}]}]}]}`,
+			want:   "cmd/x.go:7:2: G104: Errors unhandled",
+		},
+		{
+			parser: "tap",
+			report: "TAP version 13\n1..4\nok 1 - adds\nnot ok 2 - subtracts\n  ---\n  message: 'expected 1 got 2'\n  at: test/math.js:14:3\n  ...\nnot ok 3 - later # TODO not yet\nnot ok 4 divides\n",
+			want:   "test/math.js:14:3: subtracts: expected 1 got 2\ndivides: not ok",
+		},
+		{
+			parser: "junit",
+			report: `<?xml version="1.0"?>
+<testsuites><testsuite name="s">
+<testcase classname="pkg.Math" name="testAdd" file="src/math.py" line="10"><failure message="assert 3 == 4">trace</failure></testcase>
+<testcase classname="pkg.Math" name="testOk"/>
+<testcase name="testBoom"><error>RuntimeError: boom
+  at line 2</error></testcase>
+</testsuite></testsuites>`,
+			want: "src/math.py:10: pkg.Math.testAdd: assert 3 == 4\ntestBoom: RuntimeError: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.parser, func(t *testing.T) {
+			findings, err := outputParsers[tt.parser]([]byte(tt.report), []byte(tt.stderr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := findingStrings(findings); got != tt.want {
+				t.Fatalf("findings:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputParsersRejectUnparsableReports(t *testing.T) {
+	for _, parser := range []string{"go-test-json", "golangci-lint", "sarif", "tap", "junit"} {
+		if _, err := outputParsers[parser]([]byte("plain text output"), nil); err == nil {
+			t.Errorf("%s accepted plain text", parser)
+		}
+	}
+}
+
+func TestRunSingleAsyncHookCondensesParsedFailures(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+	report := `{"Issues":[{"FromLinter":"unused","Text":"func f is unused","Pos":{"Filename":"a.go","Line":3,"Column":6}}]}`
+
+	hook := onStopHook("lint", "echo '"+report+"' > report.json; for i in $(seq 500); do echo noise >&2; done; exit 1")
+	hook.Parser = "golangci-lint"
+	hook.ReportFile = "report.json"
+	r := runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir)
+	if !r.Blocking || strings.Contains(r.Stderr, "noise") || !strings.Contains(r.Stderr, "a.go:3:6: unused: func f is unused") {
+		t.Fatalf("rewake message = %q", r.Stderr)
+	}
+	if !strings.HasPrefix(r.Stderr, `hook "lint" exited 1: 1 finding(s) from golangci-lint`) {
+		t.Fatalf("rewake header = %q", r.Stderr)
+	}
+
+	content, err := os.ReadFile(filepath.Join(stateDir, "lint.findings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc hookFindings
+	if err := json.Unmarshal(content, &doc); err != nil || doc.Status != "failed" || len(doc.Findings) != 1 {
+		t.Fatalf("findings.json = %s (%v)", content, err)
+	}
+
+	// An unparsable report falls back to raw stderr.
+	hook.ReportFile = "missing.json"
+	r = runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir)
+	if !strings.Contains(r.Stderr, "noise") {
+		t.Fatalf("fallback message = %q", r.Stderr)
+	}
+
+	// So does a run that died before writing its report: the previous run's
+	// report.json is not parsed.
+	hook = onStopHook("lint", "echo 'compile error' >&2; exit 2")
+	hook.Parser = "golangci-lint"
+	hook.ReportFile = "report.json"
+	r = runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir)
+	if strings.Contains(r.Stderr, "func f is unused") || !strings.Contains(r.Stderr, "compile error") {
+		t.Fatalf("stale report message = %q", r.Stderr)
+	}
+}
@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// on_stop process control: each hook runs in its own process group, so a
+// timeout, cancel_previous or the hook's own exit takes down everything it
+// started (go test binaries, dev servers), not just the top-level shell. The
+// group gets SIGTERM, then SIGKILL after kill_grace seconds.
+//
+//	[[hooks.on_stop]]
+//	name = "test"
+//	command = "go test ./..."
+//	kill_grace = 5          # seconds between SIGTERM and SIGKILL (default 5)
+//	nice = 10               # run at this niceness
+//	memory_limit = "4G"     # address-space limit (RLIMIT_AS); K, M, G suffixes
+//	cpu_limit = 600         # CPU seconds (RLIMIT_CPU)
+//
+// Limits are set with ulimit in the hook's shell before the command runs, so
+// they apply to every process the hook starts; a hook whose limits cannot be
+// set exits 125.
+
+// defaultKillGrace is the SIGTERM-to-SIGKILL delay in seconds.
+const defaultKillGrace = 5
+
+// killGrace returns the hook's SIGTERM-to-SIGKILL delay.
+func (hc OnStopHook) killGrace() time.Duration {
+	if hc.KillGrace > 0 {
+		return time.Duration(hc.KillGrace) * time.Second
+	}
+	return defaultKillGrace * time.Second
+}
+
+// hookArgv builds the argv that runs the hook command under its limits.
+func hookArgv(hc OnStopHook) ([]string, error) {
+	var script strings.Builder
+	if hc.MemoryLimit != "" {
+		kb, err := parseMemoryLimit(hc.MemoryLimit)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&script, "ulimit -v %d || exit 125\n", kb)
+	}
+	if hc.CPULimit > 0 {
+		fmt.Fprintf(&script, "ulimit -t %d || exit 125\n", hc.CPULimit)
+	}
+	script.WriteString(hc.Command)
+
+	argv := []string{"sh", "-c", script.String()}
+	if hc.Nice != 0 {
+		argv = append([]string{"nice", "-n", strconv.Itoa(hc.Nice)}, argv...)
+	}
+	return argv, nil
+}
+
+// parseMemoryLimit converts a memory_limit such as "512M" or "4G" (plain
+// numbers are bytes) to kilobytes, the unit of ulimit -v.
+func parseMemoryLimit(limit string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(limit))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory_limit %q", limit)
+	}
+	kb := n * multiplier / 1024
+	if kb < 1 {
+		kb = 1
+	}
+	return kb, nil
+}
+
+// processGroupExists reports whether any process is left in the group led by
+// pgid.
+func processGroupExists(pgid int) bool {
+	return pgid > 0 && !errors.Is(syscall.Kill(-pgid, 0), syscall.ESRCH)
+}
+
+// terminateProcessGroup sends SIGTERM to the process group led by pgid,
+// waits up to grace for it to empty, then sends SIGKILL. It reports whether
+// the group existed.
+func terminateProcessGroup(pgid int, grace time.Duration) bool {
+	if pgid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		return !errors.Is(err, syscall.ESRCH)
+	}
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if err := syscall.Kill(-pgid, 0); errors.Is(err, syscall.ESRCH) {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	return true
+}
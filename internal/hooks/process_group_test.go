@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited (or is an unreaped zombie).
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	return strings.HasPrefix(rest, "Z")
+}
+
+func waitGone(t *testing.T, pidFile string) {
+	t.Helper()
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("grandchild pid: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !processGone(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("grandchild %d survived the hook", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRunSingleAsyncHookKillsProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("needs /proc")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+
+	// A timeout takes down the background grandchild, even one ignoring
+	// SIGTERM, after kill_grace.
+	pidFile := filepath.Join(t.TempDir(), "timeout.pid")
+	hook := onStopHook("slow", `sh -c 'trap "" TERM; sleep 30' & echo $! > `+pidFile+`; sleep 30`)
+	hook.Timeout = 1
+	hook.KillGrace = 1
+	start := time.Now()
+	if r := runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir); r.Status != "killed" {
+		t.Fatalf("status = %q", r.Status)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("hook took %s to be killed", elapsed)
+	}
+	waitGone(t, pidFile)
+
+	// Stragglers of a hook that exits normally are terminated too.
+	pidFile = filepath.Join(t.TempDir(), "leftover.pid")
+	hook = onStopHook("leaky", `sleep 30 & echo $! > `+pidFile)
+	hook.KillGrace = 1
+	if r := runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir); r.Status != "passed" {
+		t.Fatalf("status = %q", r.Status)
+	}
+	waitGone(t, pidFile)
+}
+
+func TestProcessGroupExists(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pgid := cmd.Process.Pid
+	if !processGroupExists(pgid) {
+		t.Fatal("running group reported gone")
+	}
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	if processGroupExists(pgid) {
+		t.Error("reaped group reported present")
+	}
+	if processGroupExists(0) {
+		t.Error("pgid 0 reported present")
+	}
+}
+
+func TestHookLimits(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+	out := filepath.Join(t.TempDir(), "limits")
+
+	hook := onStopHook("limited", `echo "$(ulimit -v) $(ulimit -t) $(nice)" > `+out)
+	hook.MemoryLimit = "512M"
+	hook.CPULimit = 60
+	hook.Nice = 5
+	if r := runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir); r.Status != "passed" {
+		t.Fatalf("status = %q", r.Status)
+	}
+	content, _ := os.ReadFile(out)
+	fields := strings.Fields(string(content))
+	if len(fields) != 3 || fields[0] != "524288" || fields[1] != "60" {
+		t.Fatalf("limits = %q", content)
+	}
+	if n, err := strconv.Atoi(fields[2]); err != nil || n < 5 {
+		t.Fatalf("niceness = %q", fields[2])
+	}
+
+	hook.MemoryLimit = "lots"
+	if r := runSingleAsyncHook(hook, &onStopContext{WorkingDir: work}, stateDir); r.Status != "failed" || !strings.Contains(r.Stderr, `invalid memory_limit "lots"`) {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	for in, want := range map[string]int64{"4G": 4 << 20, "512m": 512 << 10, "2048K": 2048, "1GB": 1 << 20, "1048576": 1024, "10": 1} {
+		if got, err := parseMemoryLimit(in); err != nil || got != want {
+			t.Errorf("parseMemoryLimit(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "G", "-1M", "4T"} {
+		if _, err := parseMemoryLimit(in); err == nil {
+			t.Errorf("parseMemoryLimit(%q) accepted", in)
+		}
+	}
+}
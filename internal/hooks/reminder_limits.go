@@ -0,0 +1,147 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/grovetools/core/logging"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// Reminder rate limits: per-entry options that turn a reminder into a nudge
+// instead of re-injecting it on every matching tool call.
+//
+//	[[hooks.post_tool_use]]
+//	name = "go-style"
+//	if = "Edit(*.go)"
+//	additional_context = "Run gofumpt before committing."
+//	max_per_session = 3    # deliver at most 3 times per session
+//	cooldown = "10m"       # and at most once every 10 minutes
+//	once_per_file = true   # and once per edited file
+//
+// Delivery counts live in post_tool_use_state.json in the per-session hooks
+// state dir, next to post_tool_use.log, keyed by entry name (or `if` rule).
+// Suppressed deliveries are logged to post_tool_use.log with the limit that
+// suppressed them. Without a session id nothing is limited.
+
+// reminderStateFile is the per-session delivery state for rate-limited
+// reminders.
+const reminderStateFile = "post_tool_use_state.json"
+
+// reminderDeliveries records one reminder's deliveries within a session.
+type reminderDeliveries struct {
+	Count         int       `json:"count"`
+	LastDelivered time.Time `json:"last_delivered"`
+	Files         []string  `json:"files,omitempty"`
+}
+
+// reminderMatch is a reminder whose rule and conditions matched a tool call.
+// Suppressed names the limit that held it back; empty means deliver.
+type reminderMatch struct {
+	Entry      PostToolUseReminder
+	Text       string
+	Suppressed string
+}
+
+// hasLimits reports whether any rate-limit option is set on the reminder.
+func (r PostToolUseReminder) hasLimits() bool {
+	return r.MaxPerSession > 0 || r.Cooldown != "" || r.OncePerFile
+}
+
+// applyReminderLimits marks matches that exceed their rate limits as
+// suppressed and records the remaining deliveries in the session state. The
+// state file is locked for the read-modify-write since parallel tool calls
+// fire PostToolUse concurrently.
+func applyReminderLimits(sessionID string, matches []reminderMatch, filePath string, now time.Time) {
+	limited := false
+	for _, m := range matches {
+		limited = limited || m.Entry.hasLimits()
+	}
+	if sessionID == "" || !limited {
+		return
+	}
+
+	slog := logging.NewLogger("hooks.posttooluse")
+	dir := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(dir, reminderStateFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		slog.WithFields(logrus.Fields{"error": err.Error()}).Warn("Failed to open reminder state")
+		return
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err == nil {
+		defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	}
+
+	state := map[string]*reminderDeliveries{}
+	if content, err := os.ReadFile(f.Name()); err == nil && len(content) > 0 {
+		if err := json.Unmarshal(content, &state); err != nil {
+			state = map[string]*reminderDeliveries{}
+		}
+	}
+
+	for i := range matches {
+		entry := matches[i].Entry
+		if !entry.hasLimits() {
+			continue
+		}
+		key := ruleLabel(entry.Name, entry.If)
+		record := state[key]
+		if record == nil {
+			record = &reminderDeliveries{}
+			state[key] = record
+		}
+		if reason := reminderLimitReason(entry, record, filePath, now); reason != "" {
+			matches[i].Suppressed = reason
+			continue
+		}
+		record.Count++
+		record.LastDelivered = now
+		if entry.OncePerFile && filePath != "" {
+			record.Files = append(record.Files, filePath)
+		}
+	}
+
+	out, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt(out, 0)
+	}
+}
+
+// reminderLimitReason returns why a delivery must be suppressed, or "" when
+// every limit allows it. once_per_file only applies to calls with a file.
+func reminderLimitReason(entry PostToolUseReminder, record *reminderDeliveries, filePath string, now time.Time) string {
+	if entry.MaxPerSession > 0 && record.Count >= entry.MaxPerSession {
+		return fmt.Sprintf("max_per_session (%d)", entry.MaxPerSession)
+	}
+	if entry.Cooldown != "" && !record.LastDelivered.IsZero() {
+		cooldown, err := time.ParseDuration(entry.Cooldown)
+		if err != nil {
+			logging.NewLogger("hooks.posttooluse").WithFields(logrus.Fields{
+				"reminder": entry.Name,
+				"cooldown": entry.Cooldown,
+			}).Warn("Invalid post_tool_use cooldown")
+		} else if remaining := record.LastDelivered.Add(cooldown).Sub(now); remaining > 0 {
+			return fmt.Sprintf("cooldown (%s left)", remaining.Round(time.Second))
+		}
+	}
+	if entry.OncePerFile && filePath != "" {
+		for _, seen := range record.Files {
+			if seen == filePath {
+				return "once_per_file"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+// deliver runs one tool call's matches through the limiter and returns the
+// suppression reason of each, in order.
+func deliver(sessionID string, now time.Time, filePath string, entries ...PostToolUseReminder) []string {
+	matches := make([]reminderMatch, len(entries))
+	for i, e := range entries {
+		matches[i] = reminderMatch{Entry: e, Text: "x"}
+	}
+	applyReminderLimits(sessionID, matches, filePath, now)
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.Suppressed
+	}
+	return out
+}
+
+func TestApplyReminderLimits(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("max_per_session", func(t *testing.T) {
+		r := PostToolUseReminder{Name: "max", If: "Edit(*)", MaxPerSession: 2}
+		for i, want := range []string{"", "", "max_per_session (2)"} {
+			if got := deliver("s-max", start, "", r)[0]; got != want {
+				t.Fatalf("delivery %d suppressed = %q, want %q", i, got, want)
+			}
+		}
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		r := PostToolUseReminder{Name: "cool", If: "Edit(*)", Cooldown: "10m"}
+		if got := deliver("s-cool", start, "", r)[0]; got != "" {
+			t.Fatalf("first delivery suppressed: %q", got)
+		}
+		if got := deliver("s-cool", start.Add(4*time.Minute), "", r)[0]; got != "cooldown (6m0s left)" {
+			t.Fatalf("suppressed = %q", got)
+		}
+		if got := deliver("s-cool", start.Add(11*time.Minute), "", r)[0]; got != "" {
+			t.Fatalf("delivery after cooldown suppressed: %q", got)
+		}
+	})
+
+	t.Run("once_per_file", func(t *testing.T) {
+		r := PostToolUseReminder{Name: "once", If: "Edit(*)", OncePerFile: true}
+		steps := []struct{ file, want string }{
+			{"/repo/a.go", ""},
+			{"/repo/b.go", ""},
+			{"/repo/a.go", "once_per_file"},
+			{"", ""}, // calls without a file are not limited
+		}
+		for _, s := range steps {
+			if got := deliver("s-once", start, s.file, r)[0]; got != s.want {
+				t.Fatalf("file %q suppressed = %q, want %q", s.file, got, s.want)
+			}
+		}
+	})
+
+	t.Run("unlimited entries and sessions are untouched", func(t *testing.T) {
+		plain := PostToolUseReminder{Name: "plain", If: "Edit(*)"}
+		for i := 0; i < 3; i++ {
+			if got := deliver("s-plain", start, "", plain)[0]; got != "" {
+				t.Fatalf("unlimited reminder suppressed: %q", got)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(paths.StateDir(), "hooks", "sessions", "s-plain", reminderStateFile)); err == nil {
+			t.Fatal("no state file should be written without limits")
+		}
+		limited := PostToolUseReminder{Name: "max", If: "Edit(*)", MaxPerSession: 1}
+		for i := 0; i < 2; i++ {
+			if got := deliver("", start, "", limited)[0]; got != "" {
+				t.Fatalf("reminder without session suppressed: %q", got)
+			}
+		}
+	})
+}
+
+func TestLogPostToolUseRemindersRecordsSuppressed(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	data := PostToolUseInput{SessionID: "s-log", ToolName: "Edit", ToolInput: map[string]any{"file_path": "a.go"}}
+	logPostToolUseReminders(data, []reminderMatch{
+		{Entry: PostToolUseReminder{Name: "shown"}},
+		{Entry: PostToolUseReminder{Name: "held"}, Suppressed: "once_per_file"},
+	})
+
+	content, err := os.ReadFile(filepath.Join(paths.StateDir(), "hooks", "sessions", "s-log", "post_tool_use.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), content)
+	}
+	if strings.Contains(lines[0], "suppressed") || !strings.Contains(lines[0], "hook=shown") {
+		t.Fatalf("delivered line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `hook=held tool=Edit suppressed="once_per_file"`) {
+		t.Fatalf("suppressed line = %q", lines[1])
+	}
+}
@@ -0,0 +1,198 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/grovetools/core/logging"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// Reminder templates: additional_context and include_file are Go templates
+// expanded against the tool call before delivery.
+//
+//	[[hooks.post_tool_use]]
+//	name = "owners"
+//	if = "Edit(src/**)"
+//	additional_context = "You just edited {{.RelPath}}; its owners file says:"
+//	include_file = "{{dir .FilePath}}/OWNERS"
+//
+// Fields: .ToolName, .FilePath (file_path, notebook_path or path from the
+// tool input), .RelPath (FilePath relative to .Cwd), .Command, .Cwd,
+// .SessionID, .PlanName, .JobTitle and .JobFilePath (from the session's
+// metadata.json, falling back to the GROVE_FLOW_* environment). Functions:
+// base, dir.
+//
+// include_file is resolved against .Cwd and appended after the rendered
+// context. A "#L<from>-L<to>" (or "#L<n>") suffix includes only those lines;
+// output is capped at maxIncludeBytes. A missing file includes nothing. A
+// template that fails to parse or execute is delivered verbatim.
+
+// maxIncludeBytes caps how much of an include_file is injected into the
+// transcript.
+const maxIncludeBytes = 8 * 1024
+
+// reminderVars is the data a reminder template is executed against.
+type reminderVars struct {
+	ToolName    string
+	FilePath    string
+	RelPath     string
+	Command     string
+	Cwd         string
+	SessionID   string
+	PlanName    string
+	JobTitle    string
+	JobFilePath string
+}
+
+var reminderFuncs = template.FuncMap{
+	"base": filepath.Base,
+	"dir":  filepath.Dir,
+}
+
+// newReminderVars collects template data from a hook payload and the
+// session's metadata. toolName and toolInput are empty outside tool events.
+func newReminderVars(sessionID, toolName string, toolInput map[string]any, cwd string) reminderVars {
+	vars := reminderVars{
+		ToolName:    toolName,
+		Command:     stringField(toolInput, "command"),
+		Cwd:         cwd,
+		SessionID:   sessionID,
+		PlanName:    os.Getenv("GROVE_FLOW_PLAN_NAME"),
+		JobTitle:    os.Getenv("GROVE_FLOW_JOB_TITLE"),
+		JobFilePath: os.Getenv("GROVE_FLOW_JOB_PATH"),
+	}
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if v := stringField(toolInput, key); v != "" {
+			vars.FilePath = v
+			break
+		}
+	}
+	vars.RelPath = vars.FilePath
+	if vars.FilePath != "" && cwd != "" {
+		if rel, err := filepath.Rel(cwd, vars.FilePath); err == nil && !strings.HasPrefix(rel, "..") {
+			vars.RelPath = rel
+		}
+	}
+
+	if sessionID != "" {
+		metadataFile := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID, "metadata.json")
+		if content, err := os.ReadFile(metadataFile); err == nil {
+			var metadata struct {
+				PlanName    string `json:"plan_name"`
+				JobTitle    string `json:"job_title"`
+				JobFilePath string `json:"job_file_path"`
+			}
+			if err := json.Unmarshal(content, &metadata); err == nil {
+				if metadata.PlanName != "" {
+					vars.PlanName = metadata.PlanName
+				}
+				if metadata.JobTitle != "" {
+					vars.JobTitle = metadata.JobTitle
+				}
+				if metadata.JobFilePath != "" {
+					vars.JobFilePath = metadata.JobFilePath
+				}
+			}
+		}
+	}
+	return vars
+}
+
+// renderReminder expands the reminder's additional_context and appends its
+// include_file snippet.
+func (r PostToolUseReminder) renderReminder(vars reminderVars) string {
+	return renderContext(r.Name, r.AdditionalContext, r.IncludeFile, vars)
+}
+
+// renderContext expands an additional_context template and appends the
+// include_file snippet. name identifies the config entry in warnings.
+func renderContext(name, additionalContext, includeFile string, vars reminderVars) string {
+	text := strings.TrimSpace(expandReminderTemplate(name, "additional_context", additionalContext, vars))
+	if includeFile == "" {
+		return text
+	}
+	snippet := readIncludeFile(expandReminderTemplate(name, "include_file", includeFile, vars), vars.Cwd)
+	switch {
+	case snippet == "":
+		return text
+	case text == "":
+		return snippet
+	default:
+		return text + "\n\n" + snippet
+	}
+}
+
+// expandReminderTemplate executes one template field, returning it verbatim
+// when it is not a valid template.
+func expandReminderTemplate(name, field, text string, vars reminderVars) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	slog := logging.NewLogger("hooks.posttooluse")
+	tmpl, err := template.New(field).Funcs(reminderFuncs).Option("missingkey=zero").Parse(text)
+	if err == nil {
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, vars); err == nil {
+			return buf.String()
+		}
+	}
+	slog.WithFields(logrus.Fields{
+		"reminder": name,
+		"field":    field,
+		"error":    err.Error(),
+	}).Warn("Failed to expand post_tool_use template")
+	return text
+}
+
+// includeLinesPattern matches an include_file line-range suffix.
+var includeLinesPattern = regexp.MustCompile(`#L(\d+)(?:-L?(\d+))?$`)
+
+// readIncludeFile reads an include_file reference relative to cwd, honoring a
+// #L<from>-L<to> suffix. Returns "" when the file cannot be read.
+func readIncludeFile(ref, cwd string) string {
+	ref = strings.TrimSpace(ref)
+	from, to := 0, 0
+	if m := includeLinesPattern.FindStringSubmatchIndex(ref); m != nil {
+		from, _ = strconv.Atoi(ref[m[2]:m[3]])
+		to = from
+		if m[4] >= 0 {
+			to, _ = strconv.Atoi(ref[m[4]:m[5]])
+		}
+		ref = ref[:m[0]]
+	}
+	if ref == "" {
+		return ""
+	}
+	if !filepath.IsAbs(ref) && cwd != "" {
+		ref = filepath.Join(cwd, ref)
+	}
+	content, err := os.ReadFile(ref)
+	if err != nil {
+		return ""
+	}
+	text := string(content)
+	if from > 0 {
+		lines := strings.Split(text, "\n")
+		if from > len(lines) {
+			return ""
+		}
+		if to < from || to > len(lines) {
+			to = len(lines)
+		}
+		text = strings.Join(lines[from-1:to], "\n")
+	}
+	text = strings.TrimRight(text, "\n")
+	if len(text) > maxIncludeBytes {
+		text = text[:maxIncludeBytes] + fmt.Sprintf("\n… (truncated %s at %d bytes)", filepath.Base(ref), maxIncludeBytes)
+	}
+	return text
+}
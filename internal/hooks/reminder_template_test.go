@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+func TestRenderReminder(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "src", "OWNERS"), []byte("alice\nbob\ncarol\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vars := reminderVars{
+		ToolName:  "Edit",
+		FilePath:  filepath.Join(repo, "src", "main.go"),
+		RelPath:   "src/main.go",
+		Cwd:       repo,
+		SessionID: "s1",
+		PlanName:  "auth-rework",
+		JobTitle:  "Add login",
+	}
+
+	tests := []struct {
+		name     string
+		reminder PostToolUseReminder
+		want     string
+	}{
+		{"static text untouched", PostToolUseReminder{AdditionalContext: "plain {not a template}"}, "plain {not a template}"},
+		{"fields", PostToolUseReminder{AdditionalContext: "{{.ToolName}} {{.RelPath}} in {{.PlanName}} / {{.JobTitle}} ({{.SessionID}})"}, "Edit src/main.go in auth-rework / Add login (s1)"},
+		{"funcs", PostToolUseReminder{AdditionalContext: "{{base .FilePath}}"}, "main.go"},
+		{"bad template delivered verbatim", PostToolUseReminder{AdditionalContext: "{{.Nope"}, "{{.Nope"},
+		{"unknown field delivered verbatim", PostToolUseReminder{AdditionalContext: "{{.Nope}}"}, "{{.Nope}}"},
+		{"include file", PostToolUseReminder{AdditionalContext: "Owners:", IncludeFile: "src/OWNERS"}, "Owners:\n\nalice\nbob\ncarol"},
+		{"templated include path", PostToolUseReminder{IncludeFile: "{{dir .FilePath}}/OWNERS#L2"}, "bob"},
+		{"include line range", PostToolUseReminder{IncludeFile: "src/OWNERS#L2-L3"}, "bob\ncarol"},
+		{"missing include", PostToolUseReminder{AdditionalContext: "x", IncludeFile: "nope.txt"}, "x"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.reminder.renderReminder(vars); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadIncludeFileTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", maxIncludeBytes*2)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := readIncludeFile(path, "")
+	if !strings.Contains(got, "truncated big.txt") || len(got) > maxIncludeBytes+100 {
+		t.Fatalf("include not truncated: %d bytes", len(got))
+	}
+}
+
+func TestNewReminderVars(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	t.Setenv("GROVE_FLOW_PLAN_NAME", "env-plan")
+	t.Setenv("GROVE_FLOW_JOB_TITLE", "")
+
+	input := map[string]any{"file_path": "/repo/pkg/a.go"}
+
+	vars := newReminderVars("sess-vars", "Write", input, "/repo")
+	if vars.FilePath != "/repo/pkg/a.go" || vars.RelPath != "pkg/a.go" || vars.PlanName != "env-plan" {
+		t.Fatalf("vars = %+v", vars)
+	}
+
+	dir := filepath.Join(paths.StateDir(), "hooks", "sessions", "sess-vars")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"session_id":"job-1","plan_name":"meta-plan","job_title":"Meta job","job_file_path":"/plans/p/01-job.md"}`
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vars = newReminderVars("sess-vars", "Write", input, "/elsewhere")
+	if vars.PlanName != "meta-plan" || vars.JobTitle != "Meta job" || vars.JobFilePath != "/plans/p/01-job.md" || vars.RelPath != "/repo/pkg/a.go" {
+		t.Fatalf("vars = %+v", vars)
+	}
+}
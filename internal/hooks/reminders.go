@@ -0,0 +1,164 @@
+package hooks
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grovetools/core/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// PostToolUse reminders: [[hooks.post_tool_use]] entries inject
+// additional_context when a tool call matches their `if` rule. Besides the
+// rule, an entry may condition on how the call turned out:
+//
+//	[[hooks.post_tool_use]]
+//	name = "read-failing-test"
+//	if = "Bash(go test *)"
+//	exit_code = "nonzero"
+//	output_matches = '--- FAIL'
+//	additional_context = "Tests failed — read the failing test before editing."
+//
+// Conditions (all set conditions must hold):
+//
+//	tool_error      true: Claude Code reported a tool_error; false: it did not
+//	exit_code       "zero", "nonzero", or a comma-separated list ("1,2")
+//	stdout_matches  regexp over tool_response stdout
+//	stderr_matches  regexp over tool_response stderr and tool_error
+//	output_matches  regexp over stdout, stderr and tool_error together
+//	sandbox_denied  true: the sandbox blocked a filesystem write (see
+//	                responseIndicatesSandboxDenial); false: it did not
+//
+// An entry with an invalid regexp never fires.
+
+// PostToolUseReminder is one [[hooks.post_tool_use]] entry. It extends core's
+// config.PostToolUseHook with outcome conditions and is decoded locally from
+// the hooks extension.
+type PostToolUseReminder struct {
+	Name              string `yaml:"name"`
+	If                string `yaml:"if"`
+	AdditionalContext string `yaml:"additional_context"`
+	// IncludeFile appends a repo file (or a #L<from>-L<to> slice of it) to
+	// the context; see reminder_template.go.
+	IncludeFile string `yaml:"include_file"`
+
+	ToolError     *bool  `yaml:"tool_error"`
+	ExitCode      string `yaml:"exit_code"`
+	StdoutMatches string `yaml:"stdout_matches"`
+	StderrMatches string `yaml:"stderr_matches"`
+	OutputMatches string `yaml:"output_matches"`
+	SandboxDenied *bool  `yaml:"sandbox_denied"`
+
+	// Rate limits; see reminder_limits.go. Cooldown is a Go duration.
+	MaxPerSession int    `yaml:"max_per_session"`
+	Cooldown      string `yaml:"cooldown"`
+	OncePerFile   bool   `yaml:"once_per_file"`
+}
+
+// exitCodePattern finds the exit status in Claude Code's Bash error text
+// ("Exit code 1", "exit code: 2").
+var exitCodePattern = regexp.MustCompile(`(?i)exit code:? (\d+)`)
+
+// matchesOutcome reports whether the tool call's outcome satisfies every
+// condition set on the reminder. An entry with no conditions always matches.
+func (r PostToolUseReminder) matchesOutcome(data PostToolUseInput) bool {
+	if r.ToolError != nil && *r.ToolError != (data.ToolError != nil) {
+		return false
+	}
+	if r.SandboxDenied != nil && *r.SandboxDenied != responseIndicatesSandboxDenial(data.ToolResponse) {
+		return false
+	}
+	if r.ExitCode != "" && !matchExitCode(r.ExitCode, data) {
+		return false
+	}
+	stdout, stderr := responseStreams(data)
+	for _, cond := range []struct{ field, pattern, text string }{
+		{"stdout_matches", r.StdoutMatches, stdout},
+		{"stderr_matches", r.StderrMatches, stderr},
+		{"output_matches", r.OutputMatches, stdout + "\n" + stderr},
+	} {
+		if cond.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(cond.pattern)
+		if err != nil {
+			logging.NewLogger("hooks.posttooluse").WithFields(logrus.Fields{
+				"reminder": r.Name,
+				"field":    cond.field,
+				"error":    err.Error(),
+			}).Warn("Invalid post_tool_use pattern")
+			return false
+		}
+		if !re.MatchString(cond.text) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchExitCode checks an exit_code condition against the call's exit status.
+// A failed call whose code cannot be determined still counts as nonzero.
+func matchExitCode(spec string, data PostToolUseInput) bool {
+	code, known := toolExitCode(data)
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case "zero":
+		return known && code == 0
+	case "nonzero":
+		return (known && code != 0) || (!known && data.ToolError != nil)
+	}
+	if !known {
+		return false
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if want, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && want == code {
+			return true
+		}
+	}
+	return false
+}
+
+// toolExitCode extracts a Bash exit status from tool_response (exit code
+// fields), then from the tool_error text. A Bash call without tool_error
+// exited 0. known=false means the status cannot be determined.
+func toolExitCode(data PostToolUseInput) (code int, known bool) {
+	if m, ok := data.ToolResponse.(map[string]any); ok {
+		for _, key := range []string{"exit_code", "exitCode", "returnCode", "code"} {
+			if n, ok := m[key].(float64); ok {
+				return int(n), true
+			}
+		}
+	}
+	if data.ToolError != nil {
+		if m := exitCodePattern.FindStringSubmatch(*data.ToolError); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+	if data.ToolName == "Bash" {
+		return 0, true
+	}
+	return 0, false
+}
+
+// responseStreams splits a tool_response into stdout and stderr text. A bare
+// string response counts as stdout; tool_error is appended to stderr since
+// failing commands often report their output there.
+func responseStreams(data PostToolUseInput) (stdout, stderr string) {
+	switch v := data.ToolResponse.(type) {
+	case string:
+		stdout = v
+	case map[string]any:
+		stdout, _ = v["stdout"].(string)
+		stderr, _ = v["stderr"].(string)
+	}
+	if data.ToolError != nil {
+		if stderr != "" {
+			stderr += "\n"
+		}
+		stderr += *data.ToolError
+	}
+	return stdout, stderr
+}
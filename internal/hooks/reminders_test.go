@@ -0,0 +1,72 @@
+package hooks
+
+import "testing"
+
+func TestPostToolUseReminderMatchesOutcome(t *testing.T) {
+	yes, no := true, false
+	errText := "Exit code 1\n--- FAIL: TestThing (0.00s)"
+	failed := PostToolUseInput{
+		ToolName:     "Bash",
+		ToolResponse: map[string]any{"stdout": "=== RUN TestThing", "stderr": ""},
+		ToolError:    &errText,
+	}
+	passed := PostToolUseInput{
+		ToolName:     "Bash",
+		ToolResponse: map[string]any{"stdout": "ok  \tpkg\t0.1s", "stderr": "warning: x"},
+	}
+	denied := PostToolUseInput{
+		ToolName:     "Bash",
+		ToolResponse: map[string]any{"stderr": "touch: /x: Operation not permitted"},
+	}
+	explicitCode := PostToolUseInput{
+		ToolName:     "Bash",
+		ToolResponse: map[string]any{"exit_code": float64(130)},
+	}
+
+	tests := []struct {
+		name     string
+		reminder PostToolUseReminder
+		data     PostToolUseInput
+		want     bool
+	}{
+		{"no conditions", PostToolUseReminder{}, passed, true},
+		{"tool_error true on failure", PostToolUseReminder{ToolError: &yes}, failed, true},
+		{"tool_error true on success", PostToolUseReminder{ToolError: &yes}, passed, false},
+		{"tool_error false on success", PostToolUseReminder{ToolError: &no}, passed, true},
+		{"nonzero from error text", PostToolUseReminder{ExitCode: "nonzero"}, failed, true},
+		{"nonzero on success", PostToolUseReminder{ExitCode: "nonzero"}, passed, false},
+		{"zero on success", PostToolUseReminder{ExitCode: "zero"}, passed, true},
+		{"code list from error text", PostToolUseReminder{ExitCode: "2, 1"}, failed, true},
+		{"code list from response field", PostToolUseReminder{ExitCode: "130"}, explicitCode, true},
+		{"code list miss", PostToolUseReminder{ExitCode: "2"}, failed, false},
+		{"stdout regex", PostToolUseReminder{StdoutMatches: `^ok\s`}, passed, true},
+		{"stdout regex ignores stderr", PostToolUseReminder{StdoutMatches: "warning"}, passed, false},
+		{"stderr regex sees tool_error", PostToolUseReminder{StderrMatches: "--- FAIL"}, failed, true},
+		{"output regex spans streams", PostToolUseReminder{OutputMatches: "warning: x"}, passed, true},
+		{"invalid regex never fires", PostToolUseReminder{OutputMatches: "("}, passed, false},
+		{"sandbox denied", PostToolUseReminder{SandboxDenied: &yes}, denied, true},
+		{"sandbox not denied", PostToolUseReminder{SandboxDenied: &no}, denied, false},
+		{"all conditions must hold", PostToolUseReminder{ExitCode: "nonzero", OutputMatches: "panic:"}, failed, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.reminder.matchesOutcome(tc.data); got != tc.want {
+				t.Fatalf("matchesOutcome = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToolExitCode(t *testing.T) {
+	errText := "Command failed. exit code: 2"
+	if code, known := toolExitCode(PostToolUseInput{ToolName: "Bash", ToolError: &errText}); !known || code != 2 {
+		t.Fatalf("code = %d known = %v, want 2 true", code, known)
+	}
+	opaque := "something broke"
+	if _, known := toolExitCode(PostToolUseInput{ToolName: "Bash", ToolError: &opaque}); known {
+		t.Fatal("error text without a code must not yield a known status")
+	}
+	if _, known := toolExitCode(PostToolUseInput{ToolName: "Read"}); known {
+		t.Fatal("non-Bash tools have no exit status")
+	}
+}
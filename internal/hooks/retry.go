@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// on_stop retries: a hook that fails can be rerun before the failure is
+// reported, so one flaky test does not rewake the agent.
+//
+//	[[hooks.on_stop]]
+//	name = "test"
+//	command = "go test ./..."
+//	retries = 2                  # extra attempts after a failure (default 0)
+//	retry_on_exit_codes = [1]    # only retry these exit codes (default: any)
+//
+// Timeouts are not retried. Every attempt is marked in the hook's .log, and a
+// hook that passes on a later attempt records "passed_after_retry" in its
+// .summary, which counts as a pass for hooks that need it. Each completed
+// run is also counted in a per-repo flakiness history under
+// StateDir()/hooks/flakiness, which `grove hooks list` reports.
+
+// statusPassedAfterRetry is the summary status of a pass on a retry.
+const statusPassedAfterRetry = "passed_after_retry"
+
+// hookPassed reports whether a summary status is a pass.
+func hookPassed(status string) bool {
+	return status == "passed" || status == statusPassedAfterRetry
+}
+
+// retryable reports whether an attempt that ended with status and exitCode
+// may be retried.
+func (hc OnStopHook) retryable(status string, exitCode int) bool {
+	if status != "failed" {
+		return false
+	}
+	return len(hc.RetryOnExitCodes) == 0 || slices.Contains(hc.RetryOnExitCodes, exitCode)
+}
+
+// HookFlakiness is the run history of one hook in one repo.
+type HookFlakiness struct {
+	Runs             int       `json:"runs"`
+	Failures         int       `json:"failures"`
+	PassedAfterRetry int       `json:"passed_after_retry"`
+	LastFlakyAt      time.Time `json:"last_flaky_at,omitempty"`
+}
+
+// flakinessPath is the history file for the repo at workingDir, keyed like
+// the disable markers.
+func flakinessPath(workingDir string) string {
+	return repoStateFile("flakiness", workingDir, ".json")
+}
+
+// recordHookRun counts a completed run in the repo's flakiness history.
+func recordHookRun(workingDir, hookName, status string) {
+	path := flakinessPath(workingDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err == nil {
+		defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	}
+
+	history := map[string]HookFlakiness{}
+	if content, err := os.ReadFile(path); err == nil && len(content) > 0 {
+		if err := json.Unmarshal(content, &history); err != nil {
+			history = map[string]HookFlakiness{}
+		}
+	}
+	key := slugifyHookName(hookName)
+	h := history[key]
+	h.Runs++
+	switch {
+	case status == statusPassedAfterRetry:
+		h.PassedAfterRetry++
+		h.LastFlakyAt = time.Now().UTC()
+	case !hookPassed(status):
+		h.Failures++
+	}
+	history[key] = h
+
+	out, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt(out, 0)
+	}
+}
+
+// HookFlakinessFor returns the recorded history of a hook in the repo at
+// workingDir, and whether it has any runs.
+func HookFlakinessFor(workingDir, hookName string) (HookFlakiness, bool) {
+	content, err := os.ReadFile(flakinessPath(workingDir))
+	if err != nil {
+		return HookFlakiness{}, false
+	}
+	var history map[string]HookFlakiness
+	if err := json.Unmarshal(content, &history); err != nil {
+		return HookFlakiness{}, false
+	}
+	h, ok := history[slugifyHookName(hookName)]
+	return h, ok && h.Runs > 0
+}
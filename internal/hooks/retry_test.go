@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSingleAsyncHookRetries(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work, stateDir := t.TempDir(), t.TempDir()
+	counter := filepath.Join(t.TempDir(), "attempts")
+
+	// Fails with exit 1 on the first attempt only.
+	flaky := onStopHook("flaky", `echo x >> `+counter+`; [ "$(wc -l < `+counter+`)" -gt 1 ] || { echo boom >&2; exit 1; }`)
+	flaky.Retries = 2
+	if r := runSingleAsyncHook(flaky, &onStopContext{WorkingDir: work}, stateDir); r.Status != statusPassedAfterRetry || r.Blocking {
+		t.Fatalf("result = %+v", r)
+	}
+	if got := lastSummary(t, stateDir, "flaky"); got != statusPassedAfterRetry {
+		t.Fatalf("summary = %q", got)
+	}
+	log, _ := os.ReadFile(filepath.Join(stateDir, "flaky.log"))
+	for _, want := range []string{"=== attempt 1/3 ===", "boom", "attempt 1/3 exited 1; retrying", "=== attempt 2/3 ==="} {
+		if !strings.Contains(string(log), want) {
+			t.Fatalf("log missing %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(string(log), "attempt 3/3") {
+		t.Fatalf("retried after passing:\n%s", log)
+	}
+
+	// Exit codes outside retry_on_exit_codes fail immediately.
+	broken := onStopHook("broken", `echo x >> `+counter+`.b; exit 3`)
+	broken.Retries = 2
+	broken.RetryOnExitCodes = []int{1}
+	if r := runSingleAsyncHook(broken, &onStopContext{WorkingDir: work}, stateDir); r.Status != "failed" || !strings.HasPrefix(r.Stderr, `hook "broken" exited 3`) {
+		t.Fatalf("result = %+v", r)
+	}
+	if runs, _ := os.ReadFile(counter + ".b"); strings.Count(string(runs), "x") != 1 {
+		t.Fatalf("broken ran %d times, want 1", strings.Count(string(runs), "x"))
+	}
+
+	flakiness, ok := HookFlakinessFor(work, "flaky")
+	if !ok || flakiness.Runs != 1 || flakiness.PassedAfterRetry != 1 || flakiness.LastFlakyAt.IsZero() {
+		t.Fatalf("flaky history = %+v", flakiness)
+	}
+	if flakiness, _ := HookFlakinessFor(work, "broken"); flakiness.Runs != 1 || flakiness.Failures != 1 {
+		t.Fatalf("broken history = %+v", flakiness)
+	}
+}
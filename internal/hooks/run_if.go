@@ -0,0 +1,228 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grovetools/core/pkg/daemon"
+	"github.com/grovetools/core/pkg/paths"
+)
+
+// on_stop conditions: besides run_if = "changes", an [[hooks.on_stop]] entry
+// can narrow when it runs. Every condition that is set must hold.
+//
+//	[[hooks.on_stop]]
+//	name = "go-test"
+//	command = "go test ./..."
+//	changed_paths = ["**/*.go", "go.mod", "go.sum"]  # a changed file matches one glob
+//	branches = ["main", "release/*"]                 # current branch matches one glob
+//	session_types = ["interactive_agent"]            # interactive_agent, headless_agent, claude_session
+//	providers = ["claude", "codex"]                  # claude, codex, opencode, pi
+//	exit_reasons = ["", "completed"]                 # the Stop payload's exit_reason
+//	run_if_command = "test -f go.mod"                # runs only if this exits 0
+//
+// changed_paths globs are matched against repo-relative paths of staged,
+// unstaged and untracked files ("**/" spans directories, '*' does not cross
+// '/'). A normal end-of-turn stop has an empty exit_reason. run_if_command
+// runs with sh -c in the working directory and is given runIfCommandTimeout.
+// A hook whose conditions do not hold is skipped and its .summary says which
+// condition failed; it does not hold back hooks that need it.
+
+// runIfCommandTimeout bounds a run_if_command predicate.
+const runIfCommandTimeout = 30 * time.Second
+
+// onStopContext describes the stop the on_stop hooks are running for. The
+// git-derived fields are computed on first use and shared by all hooks.
+type onStopContext struct {
+	WorkingDir  string
+	SessionType string
+	Provider    string
+	ExitReason  string
+
+	// SessionID is the grove session (flow job) id; JobFilePath and PlanDir
+	// are set when the session is linked to a flow job.
+	SessionID   string
+	JobFilePath string
+	PlanDir     string
+	// SessionStartedAt is when the session was registered, from its
+	// metadata.json; zero when unknown.
+	SessionStartedAt time.Time
+
+	// NativeSessionID is the provider's session id from the Stop payload;
+	// RunID identifies the stop-async run (on_stop_status.go).
+	NativeSessionID string
+	RunID           string
+	// Daemon receives the run's status events (on_stop_status.go); nil
+	// disables publishing.
+	Daemon daemon.Client
+
+	branchOnce sync.Once
+	branch     string
+
+	changesOnce sync.Once
+	changes     []string
+	changesErr  error
+}
+
+// newOnStopContext resolves the stopping session's ids, job, type and
+// provider from its registry metadata, falling back to the environment.
+func newOnStopContext(data StopInput, workingDir string) *onStopContext {
+	c := &onStopContext{
+		WorkingDir:  workingDir,
+		SessionType: "claude_session",
+		ExitReason:  data.ExitReason,
+		SessionID:   data.SessionID,
+
+		NativeSessionID: data.SessionID,
+	}
+	if data.SessionID != "" {
+		if content, err := os.ReadFile(filepath.Join(paths.StateDir(), "hooks", "sessions", data.SessionID, "metadata.json")); err == nil {
+			var metadata struct {
+				SessionID   string    `json:"session_id"`
+				Type        string    `json:"type"`
+				Provider    string    `json:"provider"`
+				JobFilePath string    `json:"job_file_path"`
+				StartedAt   time.Time `json:"started_at"`
+			}
+			if json.Unmarshal(content, &metadata) == nil {
+				if metadata.SessionID != "" {
+					c.SessionID = metadata.SessionID
+				}
+				if metadata.Type != "" {
+					c.SessionType = metadata.Type
+				}
+				c.Provider = metadata.Provider
+				c.JobFilePath = metadata.JobFilePath
+				c.SessionStartedAt = metadata.StartedAt
+			}
+		}
+	}
+	if c.Provider == "" {
+		c.Provider = os.Getenv("GROVE_AGENT_PROVIDER")
+	}
+	if c.Provider == "" {
+		c.Provider = "claude"
+	}
+	if c.JobFilePath == "" {
+		c.JobFilePath = os.Getenv("GROVE_FLOW_JOB_PATH")
+	}
+	if c.JobFilePath != "" {
+		c.PlanDir = filepath.Dir(c.JobFilePath)
+	}
+	return c
+}
+
+// Branch returns the current git branch of the working directory.
+func (c *onStopContext) Branch() string {
+	c.branchOnce.Do(func() {
+		c.branch = getCurrentBranch(c.WorkingDir)
+	})
+	return c.branch
+}
+
+// ChangedFiles returns the repo-relative paths of staged, unstaged and
+// untracked files in the working directory.
+func (c *onStopContext) ChangedFiles() ([]string, error) {
+	c.changesOnce.Do(func() {
+		c.changes, c.changesErr = gitChangedFiles(c.WorkingDir)
+	})
+	return c.changes, c.changesErr
+}
+
+// gitChangedFiles lists changed files from `git status --porcelain -z`, with
+// both sides of a rename.
+func gitChangedFiles(workingDir string) ([]string, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "-z", "--untracked-files=all")
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %w", err)
+	}
+	var files []string
+	entries := bytes.Split(output, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, entry[3:])
+		// Renames and copies are followed by the original path.
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			i++
+			files = append(files, string(entries[i]))
+		}
+	}
+	return files, nil
+}
+
+// skipReason evaluates the hook's run_if conditions and returns why it should
+// not run, or "" when it should.
+func (c *onStopContext) skipReason(hc OnStopHook) string {
+	if hc.RunIf == "changes" {
+		hasChanges, err := hasGitChanges(c.WorkingDir)
+		if err != nil || !hasChanges {
+			return "run_if changes: no changes"
+		}
+	}
+
+	if len(hc.ChangedPaths) > 0 {
+		files, err := c.ChangedFiles()
+		if err != nil {
+			return "changed_paths: " + err.Error()
+		}
+		matched := slices.ContainsFunc(files, func(file string) bool {
+			return slices.ContainsFunc(hc.ChangedPaths, func(pattern string) bool {
+				return matchGlob(pattern, file, true)
+			})
+		})
+		if !matched {
+			return "changed_paths: no matching changes"
+		}
+	}
+
+	if len(hc.Branches) > 0 {
+		branch := c.Branch()
+		if !slices.ContainsFunc(hc.Branches, func(pattern string) bool {
+			return matchGlob(pattern, branch, false)
+		}) {
+			return fmt.Sprintf("branch %s not in branches", branch)
+		}
+	}
+
+	if len(hc.SessionTypes) > 0 && !slices.Contains(hc.SessionTypes, c.SessionType) {
+		return fmt.Sprintf("session type %s not in session_types", c.SessionType)
+	}
+	if len(hc.Providers) > 0 && !slices.Contains(hc.Providers, c.Provider) {
+		return fmt.Sprintf("provider %s not in providers", c.Provider)
+	}
+	if len(hc.ExitReasons) > 0 && !slices.Contains(hc.ExitReasons, c.ExitReason) {
+		return fmt.Sprintf("exit reason %q not in exit_reasons", c.ExitReason)
+	}
+
+	if hc.RunIfCommand != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), runIfCommandTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", hc.RunIfCommand) //nolint:gosec // command from trusted config
+		cmd.Dir = c.WorkingDir
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			return "run_if_command timed out"
+		case errors.As(err, &exitErr):
+			return fmt.Sprintf("run_if_command exited %d", exitErr.ExitCode())
+		case err != nil:
+			return "run_if_command: " + err.Error()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestOnStopContextSkipReason(t *testing.T) {
+	repo := initGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(repo, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "docs", "guide.md"), []byte("docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stop := &onStopContext{WorkingDir: repo, SessionType: "interactive_agent", Provider: "codex", ExitReason: "completed"}
+
+	tests := []struct {
+		name string
+		hook OnStopHook
+		want string
+	}{
+		{"no conditions", OnStopHook{}, ""},
+		{"docs-only change skips go", OnStopHook{ChangedPaths: []string{"**/*.go", "go.mod"}}, "changed_paths: no matching changes"},
+		{"nested glob matches", OnStopHook{ChangedPaths: []string{"**/*.md"}}, ""},
+		{"branch matches", OnStopHook{Branches: []string{"release/*", "main"}}, ""},
+		{"branch mismatch", OnStopHook{Branches: []string{"release/*"}}, "branch main not in branches"},
+		{"session type", OnStopHook{SessionTypes: []string{"headless_agent"}}, "session type interactive_agent not in session_types"},
+		{"provider", OnStopHook{Providers: []string{"claude", "codex"}}, ""},
+		{"provider mismatch", OnStopHook{Providers: []string{"claude"}}, "provider codex not in providers"},
+		{"exit reason", OnStopHook{ExitReasons: []string{""}}, `exit reason "completed" not in exit_reasons`},
+		{"command passes", OnStopHook{RunIfCommand: "test -f docs/guide.md"}, ""},
+		{"command fails", OnStopHook{RunIfCommand: "exit 3"}, "run_if_command exited 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stop.skipReason(tt.hook); got != tt.want {
+				t.Fatalf("skipReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	repo := initGitRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "old.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "old.go")
+	run("commit", "-q", "-m", "add")
+	run("mv", "old.go", "new.go")
+	if err := os.MkdirAll(filepath.Join(repo, "pkg", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "pkg", "sub", "x.go"), []byte("package sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := gitChangedFiles(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"new.go": true, "old.go": true, "pkg/sub/x.go": true}
+	if len(files) != len(want) {
+		t.Fatalf("files = %q", files)
+	}
+	for _, f := range files {
+		if !want[f] {
+			t.Fatalf("unexpected file %q in %q", f, files)
+		}
+	}
+}
+
+func TestNewOnStopContextDefaults(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	t.Setenv("GROVE_AGENT_PROVIDER", "")
+
+	c := newOnStopContext(StopInput{SessionID: "none", ExitReason: "error"}, "/tmp")
+	if c.SessionType != "claude_session" || c.Provider != "claude" || c.ExitReason != "error" {
+		t.Fatalf("defaults = %+v", c)
+	}
+}
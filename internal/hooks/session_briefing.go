@@ -0,0 +1,328 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grovetools/core/config"
+	"github.com/grovetools/core/pkg/paths"
+)
+
+// SessionStart briefing: an opt-in additionalContext response that tells an
+// agent started (or resumed) on a flow job what happened before it:
+//
+//	[hooks.session_start]
+//	briefing = true
+//
+// The session is linked to its job via GROVE_FLOW_JOB_ID/GROVE_FLOW_JOB_PATH
+// or the job_file_path in its metadata.json. The briefing lists the job's
+// title and status, each depends_on job's summary or "## Output" section, the
+// outcome of the job's most recent earlier session, and the on_stop hooks
+// that failed in that session with the tail of their logs. Sessions not
+// linked to a job get no briefing.
+
+// Briefing size caps, so a chatty dependency or hook log cannot crowd out
+// the rest of the context window.
+const (
+	maxBriefingOutputBytes = 2 * 1024
+	maxBriefingLogLines    = 20
+	maxBriefingBytes       = 12 * 1024
+)
+
+// jobFrontmatter is the subset of a flow job's frontmatter the briefing uses.
+type jobFrontmatter struct {
+	Title     string
+	Status    string
+	Summary   string
+	DependsOn []string
+}
+
+// priorSession is an earlier session of the same job, read from its
+// registry directory.
+type priorSession struct {
+	Dir         string
+	Status      string
+	EndReason   string
+	StartedAt   time.Time
+	EndedAt     string
+	JobFilePath string
+}
+
+// sessionBriefingEnabled reports whether [hooks.session_start] briefing is
+// set in the grove.toml governing workingDir.
+func sessionBriefingEnabled(workingDir string) bool {
+	if workingDir == "" {
+		return false
+	}
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return false
+	}
+	var hooksConfig struct {
+		SessionStart struct {
+			Briefing bool `yaml:"briefing"`
+		} `yaml:"session_start"`
+	}
+	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
+		return false
+	}
+	return hooksConfig.SessionStart.Briefing
+}
+
+// parseJobFile splits a flow job file into its frontmatter fields and body.
+// depends_on may be an inline ([a, b]) or block (- a) list.
+func parseJobFile(content string) (jobFrontmatter, string) {
+	var fm jobFrontmatter
+	if !strings.HasPrefix(content, "---") {
+		return fm, content
+	}
+	parts := strings.SplitN(content, "---", 3)
+	if len(parts) < 3 {
+		return fm, content
+	}
+	inDependsOn := false
+	for _, line := range strings.Split(parts[1], "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inDependsOn && strings.HasPrefix(trimmed, "- ") {
+			fm.DependsOn = append(fm.DependsOn, unquoteYAML(strings.TrimPrefix(trimmed, "- ")))
+			continue
+		}
+		inDependsOn = false
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "title":
+			fm.Title = unquoteYAML(value)
+		case "status":
+			fm.Status = unquoteYAML(value)
+		case "summary":
+			fm.Summary = unquoteYAML(value)
+		case "depends_on":
+			if value == "" {
+				inDependsOn = true
+				continue
+			}
+			for _, dep := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if dep = unquoteYAML(dep); dep != "" {
+					fm.DependsOn = append(fm.DependsOn, dep)
+				}
+			}
+		}
+	}
+	return fm, parts[2]
+}
+
+// unquoteYAML strips whitespace and surrounding quotes from a scalar.
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// jobOutputSection returns the body of the job's "## Output" section, up to
+// the next level-2 heading.
+func jobOutputSection(body string) string {
+	_, after, ok := strings.Cut(body, "\n## Output")
+	if !ok {
+		if !strings.HasPrefix(body, "## Output") {
+			return ""
+		}
+		after = strings.TrimPrefix(body, "## Output")
+	}
+	if _, rest, ok := strings.Cut(after, "\n"); ok {
+		after = rest
+	} else {
+		return ""
+	}
+	if i := strings.Index(after, "\n## "); i >= 0 {
+		after = after[:i]
+	}
+	return strings.TrimSpace(after)
+}
+
+// truncateBriefing caps s at max bytes, noting what was dropped.
+func truncateBriefing(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + fmt.Sprintf("\n… (truncated at %d bytes)", max)
+}
+
+// findPriorSession returns the most recently started session registered for
+// jobID, or nil. Registry directories are named by provider session id and
+// carry the job id as metadata session_id.
+func findPriorSession(jobID string) *priorSession {
+	if jobID == "" {
+		return nil
+	}
+	sessionsDir := filepath.Join(paths.StateDir(), "hooks", "sessions")
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil {
+		return nil
+	}
+	var latest *priorSession
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(sessionsDir, entry.Name())
+		content, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+		if err != nil {
+			continue
+		}
+		var metadata struct {
+			SessionID   string    `json:"session_id"`
+			Status      string    `json:"status"`
+			EndReason   string    `json:"end_reason"`
+			StartedAt   time.Time `json:"started_at"`
+			EndedAt     string    `json:"ended_at"`
+			JobFilePath string    `json:"job_file_path"`
+		}
+		if err := json.Unmarshal(content, &metadata); err != nil || metadata.SessionID != jobID {
+			continue
+		}
+		if latest != nil && !metadata.StartedAt.After(latest.StartedAt) {
+			continue
+		}
+		latest = &priorSession{
+			Dir:         dir,
+			Status:      metadata.Status,
+			EndReason:   metadata.EndReason,
+			StartedAt:   metadata.StartedAt,
+			EndedAt:     metadata.EndedAt,
+			JobFilePath: metadata.JobFilePath,
+		}
+	}
+	return latest
+}
+
+// failedOnStopHooks describes the on_stop hooks whose latest run in the
+// session dir failed or was killed, with the tail of each log.
+func failedOnStopHooks(sessionDir string) []string {
+	stateDir := filepath.Join(sessionDir, "on_stop")
+	summaries, err := filepath.Glob(filepath.Join(stateDir, "*.summary"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(summaries)
+	var failed []string
+	for _, summaryPath := range summaries {
+		content, err := os.ReadFile(summaryPath)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		last := lines[len(lines)-1]
+		// Summary lines are "[<timestamp>] <status>".
+		_, status, _ := strings.Cut(last, "] ")
+		status = strings.TrimSuffix(status, " (cached)")
+		if status != "failed" && status != "killed" {
+			continue
+		}
+		slug := strings.TrimSuffix(filepath.Base(summaryPath), ".summary")
+		text := fmt.Sprintf("- %s: %s", slug, last)
+		if tail, err := readTailLines(filepath.Join(stateDir, slug+".log"), maxBriefingLogLines); err == nil && len(tail) > 0 {
+			text += "\n```\n" + strings.TrimRight(string(tail), "\n") + "\n```"
+		}
+		failed = append(failed, text)
+	}
+	return failed
+}
+
+// buildSessionBriefing assembles the briefing for a session, or "" when the
+// session is not linked to a flow job. It must run before the session is
+// (re)registered, since registration may replace a stale session dir.
+func buildSessionBriefing(sessionID string) string {
+	jobID := os.Getenv("GROVE_FLOW_JOB_ID")
+	jobFilePath := os.Getenv("GROVE_FLOW_JOB_PATH")
+	if sessionID != "" {
+		if content, err := os.ReadFile(filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID, "metadata.json")); err == nil {
+			var metadata struct {
+				SessionID   string `json:"session_id"`
+				JobFilePath string `json:"job_file_path"`
+			}
+			if json.Unmarshal(content, &metadata) == nil {
+				if jobID == "" && metadata.JobFilePath != "" {
+					jobID = metadata.SessionID
+				}
+				if jobFilePath == "" {
+					jobFilePath = metadata.JobFilePath
+				}
+			}
+		}
+	}
+	if jobID == "" && jobFilePath == "" {
+		return ""
+	}
+
+	prior := findPriorSession(jobID)
+	if jobFilePath == "" && prior != nil {
+		jobFilePath = prior.JobFilePath
+	}
+
+	var b strings.Builder
+	b.WriteString("# Grove flow job briefing\n")
+
+	if content, err := os.ReadFile(jobFilePath); err == nil {
+		job, _ := parseJobFile(string(content))
+		fmt.Fprintf(&b, "\n## Job\n\n%s (status: %s)\n%s\n", job.Title, job.Status, jobFilePath)
+
+		if len(job.DependsOn) > 0 {
+			b.WriteString("\n## Dependencies\n")
+			for _, dep := range job.DependsOn {
+				depPath := dep
+				if !filepath.IsAbs(depPath) {
+					depPath = filepath.Join(filepath.Dir(jobFilePath), dep)
+				}
+				depContent, err := os.ReadFile(depPath)
+				if err != nil {
+					fmt.Fprintf(&b, "\n### %s\n\n(not found)\n", dep)
+					continue
+				}
+				depJob, depBody := parseJobFile(string(depContent))
+				fmt.Fprintf(&b, "\n### %s — %s (status: %s)\n", dep, depJob.Title, depJob.Status)
+				if depJob.Summary != "" {
+					fmt.Fprintf(&b, "\nSummary: %s\n", depJob.Summary)
+				}
+				if output := jobOutputSection(depBody); output != "" {
+					fmt.Fprintf(&b, "\n%s\n", truncateBriefing(output, maxBriefingOutputBytes))
+				}
+			}
+		}
+	} else if jobID != "" {
+		fmt.Fprintf(&b, "\n## Job\n\n%s\n", jobID)
+	}
+
+	if prior != nil {
+		status := prior.Status
+		if status == "" {
+			status = "no terminal status recorded"
+		}
+		fmt.Fprintf(&b, "\n## Last session\n\nStarted %s; outcome: %s", prior.StartedAt.Format(time.RFC3339), status)
+		if prior.EndedAt != "" {
+			fmt.Fprintf(&b, "; ended %s", prior.EndedAt)
+		}
+		if prior.EndReason != "" {
+			fmt.Fprintf(&b, " (%s)", prior.EndReason)
+		}
+		b.WriteString("\n")
+
+		if failed := failedOnStopHooks(prior.Dir); len(failed) > 0 {
+			b.WriteString("\n## Failed on_stop hooks\n\n")
+			b.WriteString(strings.Join(failed, "\n"))
+			b.WriteString("\n")
+		}
+	}
+
+	return truncateBriefing(strings.TrimRight(b.String(), "\n"), maxBriefingBytes)
+}
@@ -0,0 +1,102 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grovetools/core/pkg/paths"
+)
+
+func TestParseJobFile(t *testing.T) {
+	inline := "---\nid: job-2\ntitle: \"Wire the API\"\nstatus: running\ndepends_on: [01-design.md, '02-schema.md']\n---\n\n# Body\n"
+	fm, body := parseJobFile(inline)
+	if fm.Title != "Wire the API" || fm.Status != "running" || strings.Join(fm.DependsOn, ",") != "01-design.md,02-schema.md" {
+		t.Fatalf("inline frontmatter = %+v", fm)
+	}
+	if strings.TrimSpace(body) != "# Body" {
+		t.Fatalf("body = %q", body)
+	}
+
+	block := "---\ntitle: Design\ndepends_on:\n  - 00-spec.md\n  - \"00-notes.md\"\nsummary: Chose REST.\n---\nbody"
+	fm, _ = parseJobFile(block)
+	if strings.Join(fm.DependsOn, ",") != "00-spec.md,00-notes.md" || fm.Summary != "Chose REST." {
+		t.Fatalf("block frontmatter = %+v", fm)
+	}
+
+	fm, body = parseJobFile("no frontmatter")
+	if fm.Title != "" || body != "no frontmatter" {
+		t.Fatalf("plain file = %+v %q", fm, body)
+	}
+}
+
+func TestJobOutputSection(t *testing.T) {
+	body := "\n# Task\n\nDo it.\n\n## Output\n\nDone: added /v1/users.\n\n## Notes\n\nignored\n"
+	if got := jobOutputSection(body); got != "Done: added /v1/users." {
+		t.Fatalf("output = %q", got)
+	}
+	if got := jobOutputSection("## Output\nfirst line"); got != "first line" {
+		t.Fatalf("leading output = %q", got)
+	}
+	if got := jobOutputSection("# Task\nno output yet"); got != "" {
+		t.Fatalf("missing output = %q", got)
+	}
+}
+
+func TestBuildSessionBriefing(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	t.Setenv("GROVE_FLOW_JOB_ID", "")
+	t.Setenv("GROVE_FLOW_JOB_PATH", "")
+
+	if got := buildSessionBriefing("unlinked"); got != "" {
+		t.Fatalf("unlinked session got a briefing: %q", got)
+	}
+
+	planDir := t.TempDir()
+	jobFile := filepath.Join(planDir, "02-api.md")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(jobFile, "---\ntitle: Build API\nstatus: running\ndepends_on: [01-design.md, 00-missing.md]\n---\nDo it.\n")
+	write(filepath.Join(planDir, "01-design.md"), "---\ntitle: Design\nstatus: completed\nsummary: Chose REST.\n---\n\n## Output\n\nEndpoints: /v1/users\n")
+
+	sessionsDir := filepath.Join(paths.StateDir(), "hooks", "sessions")
+	write(filepath.Join(sessionsDir, "old-claude", "metadata.json"),
+		`{"session_id":"job-api","job_file_path":"`+jobFile+`","started_at":"2026-01-01T00:00:00Z","status":"interrupted"}`)
+	write(filepath.Join(sessionsDir, "newer-claude", "metadata.json"),
+		`{"session_id":"job-api","job_file_path":"`+jobFile+`","started_at":"2026-01-02T00:00:00Z","status":"completed","ended_at":"2026-01-02T01:00:00Z","end_reason":"prompt_input_exit"}`)
+	write(filepath.Join(sessionsDir, "newer-claude", "on_stop", "lint.summary"), "[2026-01-02T00:30:00Z] passed\n[2026-01-02T00:59:00Z] failed\n")
+	write(filepath.Join(sessionsDir, "newer-claude", "on_stop", "lint.log"), "main.go:3: unused import\n")
+	write(filepath.Join(sessionsDir, "newer-claude", "on_stop", "test.summary"), "[2026-01-02T00:59:00Z] passed\n")
+	write(filepath.Join(sessionsDir, "other-job", "metadata.json"),
+		`{"session_id":"job-other","started_at":"2026-02-01T00:00:00Z","status":"failed"}`)
+
+	t.Setenv("GROVE_FLOW_JOB_ID", "job-api")
+	got := buildSessionBriefing("fresh-claude")
+
+	for _, want := range []string{
+		"Build API (status: running)",
+		"01-design.md — Design (status: completed)",
+		"Summary: Chose REST.",
+		"Endpoints: /v1/users",
+		"### 00-missing.md\n\n(not found)",
+		"outcome: completed; ended 2026-01-02T01:00:00Z (prompt_input_exit)",
+		"- lint: [2026-01-02T00:59:00Z] failed",
+		"main.go:3: unused import",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("briefing missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "- test:") || strings.Contains(got, "interrupted") {
+		t.Errorf("briefing includes passing hook or older session:\n%s", got)
+	}
+}
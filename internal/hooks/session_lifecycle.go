@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/grovetools/core/logging"
 	"github.com/grovetools/core/pkg/models"
@@ -25,6 +26,11 @@ import (
 //     session.deleted). Terminal — unlike the Stop hook this also cleans up
 //     the filesystem registry entry, because the provider-side session (and
 //     its transcript fragments) are gone.
+//
+// Claude Code's native SessionEnd is routed to the same session-end command.
+// Its payload carries transcript_path, which the provider integrations never
+// send; for those sessions the transcript survives, so the session is
+// finalized in place instead (see finalizeEndedSession).
 
 // NormalizeProviderSessionStatus maps a provider-reported status to the
 // session-store vocabulary. Provider activity states (opencode "busy",
@@ -118,12 +124,16 @@ func RunSessionStatusHook() {
 	}).Info("Session status updated")
 }
 
-// RunSessionEndHook handles the session-end hook: a provider integration
-// reporting that the session was destroyed on the provider side. The session
-// is marked completed in the daemon and its filesystem registry entry is
-// removed (parity with the pre-v2 opencode plugin, which deleted the entry on
-// session.deleted — there is no transcript left to archive once opencode
-// drops the session).
+// RunSessionEndHook handles the session-end hook, which has two sources:
+//
+//   - Claude Code's native SessionEnd, recognized by its transcript_path: the
+//     Claude process is exiting, so finalizeEndedSession records a terminal
+//     status and keeps the registry entry for transcript archiving.
+//   - A provider integration reporting that the session was destroyed on the
+//     provider side: the session is marked completed in the daemon and its
+//     filesystem registry entry is removed (parity with the pre-v2 opencode
+//     plugin, which deleted the entry on session.deleted — there is no
+//     transcript left to archive once opencode drops the session).
 func RunSessionEndHook() {
 	slog := logging.NewLogger("hooks.session-end")
 
@@ -153,6 +163,11 @@ func RunSessionEndHook() {
 		slog.WithError(err).Debug("Failed to log session_end event")
 	}
 
+	if data.TranscriptPath != "" {
+		finalizeEndedSession(ctx, slog, data, actualSessionID)
+		return
+	}
+
 	// "completed" is terminal: DaemonBackend routes it to EndSession.
 	if err := ctx.Storage.UpdateSessionStatus(actualSessionID, "completed"); err != nil {
 		slog.WithFields(logrus.Fields{
@@ -179,3 +194,66 @@ func RunSessionEndHook() {
 		"reason":     data.Reason,
 	}).Info("Session ended and registry entry removed")
 }
+
+// finalizeEndedSession handles Claude Code's native SessionEnd: the Claude
+// process is exiting, so the session gets a terminal status in the daemon
+// (routed to EndSession) and in metadata.json without waiting for Stop
+// heuristics or the cleanup sweep. The registry directory is kept for
+// transcript archiving, as in the Stop pipeline.
+func finalizeEndedSession(ctx *HookContext, slog *logrus.Entry, data SessionEndInput, actualSessionID string) {
+	metadataPath := filepath.Join(paths.StateDir(), "hooks", "sessions", data.SessionID, "metadata.json")
+	status := finalizeSessionMetadata(metadataPath, data.Reason, time.Now())
+
+	if err := ctx.Storage.UpdateSessionStatus(actualSessionID, status); err != nil {
+		slog.WithFields(logrus.Fields{
+			"session_id": actualSessionID,
+			"status":     status,
+			"error":      err.Error(),
+		}).Warn("Failed to finalize session status")
+	}
+
+	slog.WithFields(logrus.Fields{
+		"session_id": actualSessionID,
+		"directory":  data.SessionID,
+		"reason":     data.Reason,
+		"status":     status,
+	}).Info("Session ended and finalized")
+}
+
+// finalizeSessionMetadata records the end of a session in metadata.json and
+// returns the terminal status to report. A terminal status already written
+// by the Stop pipeline (e.g. failed) is kept; anything else becomes
+// completed. A missing metadata.json is left alone.
+func finalizeSessionMetadata(metadataPath, reason string, now time.Time) string {
+	status := "completed"
+	content, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return status
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return status
+	}
+	if existing, _ := metadata["status"].(string); isTerminalSessionStatus(existing) {
+		status = existing
+	}
+	metadata["status"] = status
+	metadata["ended_at"] = now.Format(time.RFC3339)
+	if reason != "" {
+		metadata["end_reason"] = reason
+	}
+	if updated, err := json.MarshalIndent(metadata, "", "  "); err == nil {
+		_ = os.WriteFile(metadataPath, updated, 0o644)
+	}
+	return status
+}
+
+// isTerminalSessionStatus mirrors the statuses DaemonBackend routes to
+// EndSession.
+func isTerminalSessionStatus(status string) bool {
+	switch status {
+	case "completed", "failed", "error", "interrupted":
+		return true
+	}
+	return false
+}
@@ -1,9 +1,11 @@
 package hooks
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNormalizeProviderSessionStatus(t *testing.T) {
@@ -79,3 +81,43 @@ func TestResolveRegisteredSessionID(t *testing.T) {
 		t.Errorf("resolveRegisteredSessionID(%q) = %q, want %q", bareID, got, bareID)
 	}
 }
+
+func TestFinalizeSessionMetadata(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	running := filepath.Join(dir, "running.json")
+	if err := os.WriteFile(running, []byte(`{"session_id": "job-1", "status": "running"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := finalizeSessionMetadata(running, "prompt_input_exit", now); got != "completed" {
+		t.Errorf("status = %q, want completed", got)
+	}
+	var metadata map[string]any
+	content, _ := os.ReadFile(running)
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata["status"] != "completed" || metadata["end_reason"] != "prompt_input_exit" ||
+		metadata["ended_at"] != "2026-03-04T05:06:07Z" || metadata["session_id"] != "job-1" {
+		t.Errorf("metadata not finalized: %v", metadata)
+	}
+
+	// A terminal status from the Stop pipeline is kept.
+	failed := filepath.Join(dir, "failed.json")
+	if err := os.WriteFile(failed, []byte(`{"status": "failed"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := finalizeSessionMetadata(failed, "other", now); got != "failed" {
+		t.Errorf("status = %q, want failed", got)
+	}
+
+	// No metadata: completed, nothing written.
+	missing := filepath.Join(dir, "missing.json")
+	if got := finalizeSessionMetadata(missing, "", now); got != "completed" {
+		t.Errorf("status = %q, want completed", got)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("metadata created for unknown session: %v", err)
+	}
+}
@@ -0,0 +1,628 @@
+package hooks
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Shell parsing for Bash tool calls. Permission rules and the commands.jsonl
+// recorder both need the programs a command line actually executes, which a
+// plain split on && ; | gets wrong as soon as quoting or nesting is involved:
+//
+//	cd x && bash -c "rm -rf y"     -> cd x | bash -c "rm -rf y" | rm -rf y
+//	echo "a && b" > out            -> echo "a && b" > out
+//	git log $(git rev-parse HEAD)  -> git log $(git rev-parse HEAD) | git rev-parse HEAD
+//	sudo env FOO=1 make install    -> sudo env ... | env FOO=1 make install | make install
+//
+// parseShellCommands is a best-effort POSIX tokenizer and walker, not a full
+// shell grammar. It understands quoting and escapes, the list and pipeline
+// operators, subshells and brace groups, $(...) and backtick substitutions,
+// <(...) process substitutions, redirections, here-documents (whose bodies are
+// parsed when fed to a shell), leading NAME=value assignments and the common
+// reserved words. Commands run through sh/bash -c, eval, find -exec and
+// prefix wrappers (env, sudo, xargs, nice, timeout, ...) are unwrapped into
+// additional entries. Malformed input never fails: an unterminated quote or
+// substitution simply runs to the end of the input.
+
+// maxShellDepth bounds how deeply substitutions and sh -c wrappers are
+// followed, so adversarial input cannot recurse without limit.
+const maxShellDepth = 8
+
+// shellCommand is one program a command line executes.
+type shellCommand struct {
+	// Text is the command's source text (including its redirections) for
+	// commands written out in the input, or the space-joined Argv for
+	// commands recovered by unwrapping a wrapper.
+	Text string
+	// Argv is the program and its arguments after quote removal, without
+	// assignments and redirections.
+	Argv []string
+}
+
+// parseShellCommands returns every command src executes, in source order,
+// each followed by the commands nested inside it.
+func parseShellCommands(src string) []shellCommand {
+	return parseShellDepth(src, 0)
+}
+
+func parseShellDepth(src string, depth int) []shellCommand {
+	if depth > maxShellDepth {
+		return nil
+	}
+	p := &shellParser{src: src, depth: depth}
+	p.parse()
+	return p.out
+}
+
+// shellCommandStrings returns the strings a Bash permission glob is matched
+// against for one command: its source text and, when different, its argv
+// with quotes removed and redirections dropped.
+func shellCommandStrings(cmd shellCommand) []string {
+	joined := strings.Join(cmd.Argv, " ")
+	if joined == cmd.Text || joined == "" {
+		return []string{cmd.Text}
+	}
+	return []string{cmd.Text, joined}
+}
+
+// heredoc is a here-document waiting for its body, which starts on the line
+// after the redirection.
+type heredoc struct {
+	delim     string
+	stripTabs bool
+	owner     []string // argv of the command the body is fed to
+}
+
+type shellParser struct {
+	src   string
+	pos   int
+	depth int
+	out   []shellCommand
+
+	// Current simple command.
+	start, end int
+	argv       []string
+	nested     []shellCommand
+	heredocs   []*heredoc
+	skipWords  bool // inside for/select/case headers
+
+	pending []*heredoc
+}
+
+// shellReserved are reserved words that may precede a command without being
+// one themselves.
+var shellReserved = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true, "esac": true,
+	"!": true, "{": true, "}": true, "time": true,
+}
+
+func (p *shellParser) parse() {
+	p.resetCommand()
+	for {
+		p.skipBlanks()
+		if p.pos >= len(p.src) {
+			p.flush()
+			return
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '\n':
+			p.flush()
+			p.pos++
+			p.readHeredocBodies()
+		case c == ';' || c == '|' || c == '(' || c == ')' || (c == '&' && !p.hasPrefix("&>")):
+			p.flush()
+			p.pos++
+			if p.pos < len(p.src) && (p.src[p.pos] == c || (c == '|' && p.src[p.pos] == '&')) {
+				p.pos++
+			}
+		case (c == '<' || c == '>') && p.hasPrefixAt(p.pos+1, "("):
+			p.mark()
+			start := p.pos
+			p.pos++
+			p.readSubstitution('(', ')')
+			p.argv = append(p.argv, p.src[start:p.pos])
+			p.end = p.pos
+		case p.atRedirection():
+			p.readRedirection()
+		default:
+			start := p.pos
+			word := p.readWord()
+			p.addWord(word, p.src[start:p.pos], start)
+		}
+	}
+}
+
+func (p *shellParser) resetCommand() {
+	p.start, p.end = -1, -1
+	p.argv = nil
+	p.nested = nil
+	p.heredocs = nil
+	p.skipWords = false
+}
+
+// mark records the start of the current simple command.
+func (p *shellParser) mark() {
+	if p.start < 0 {
+		p.start = p.pos
+	}
+}
+
+func (p *shellParser) addWord(word, raw string, start int) {
+	if p.start < 0 {
+		p.start = start
+	}
+	p.end = p.pos
+	if p.skipWords {
+		return
+	}
+	if len(p.argv) == 0 {
+		if shellReserved[raw] {
+			// The command proper starts after the reserved word.
+			p.start = -1
+			return
+		}
+		switch raw {
+		case "for", "select", "case":
+			p.skipWords = true
+			return
+		}
+		if isShellAssignment(raw) {
+			return
+		}
+	}
+	p.argv = append(p.argv, word)
+}
+
+// flush emits the current simple command, followed by the commands it wraps
+// and those nested in its substitutions.
+func (p *shellParser) flush() {
+	for _, h := range p.heredocs {
+		h.owner = p.argv
+		p.pending = append(p.pending, h)
+	}
+	if len(p.argv) > 0 {
+		text := strings.TrimSpace(p.src[p.start:p.end])
+		p.out = append(p.out, shellCommand{Text: text, Argv: p.argv})
+		p.out = append(p.out, unwrapShellCommand(p.argv, p.depth)...)
+	}
+	p.out = append(p.out, p.nested...)
+	p.resetCommand()
+}
+
+func (p *shellParser) hasPrefix(s string) bool { return p.hasPrefixAt(p.pos, s) }
+
+func (p *shellParser) hasPrefixAt(i int, s string) bool {
+	return i <= len(p.src) && strings.HasPrefix(p.src[i:], s)
+}
+
+func (p *shellParser) skipBlanks() {
+	for p.pos < len(p.src) {
+		switch {
+		case p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// atRedirection reports whether a redirection operator, optionally preceded
+// by a file descriptor number, starts at the current position.
+func (p *shellParser) atRedirection() bool {
+	i := p.pos
+	for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+		i++
+	}
+	if i < len(p.src) && (p.src[i] == '<' || p.src[i] == '>') {
+		return true
+	}
+	return i == p.pos && p.hasPrefix("&>")
+}
+
+// readRedirection consumes a redirection and its target. Here-document
+// delimiters are queued so the body can be skipped (or parsed) after the
+// current line.
+func (p *shellParser) readRedirection() {
+	p.mark()
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	var op string
+	for _, candidate := range []string{"<<<", "<<-", "&>>", "<<", ">>", ">&", "<&", "<>", ">|", "&>", "<", ">"} {
+		if p.hasPrefix(candidate) {
+			op = candidate
+			break
+		}
+	}
+	p.pos += len(op)
+	p.skipBlanks()
+	target := p.readWord()
+	p.end = p.pos
+	if op == "<<" || op == "<<-" {
+		p.heredocs = append(p.heredocs, &heredoc{delim: target, stripTabs: op == "<<-"})
+	}
+}
+
+// readHeredocBodies consumes the bodies of here-documents opened on the line
+// just ended. A body fed to a shell reading stdin is parsed as commands.
+func (p *shellParser) readHeredocBodies() {
+	for _, h := range p.pending {
+		var body strings.Builder
+		for p.pos < len(p.src) {
+			lineEnd := strings.IndexByte(p.src[p.pos:], '\n')
+			var line string
+			if lineEnd < 0 {
+				line, p.pos = p.src[p.pos:], len(p.src)
+			} else {
+				line, p.pos = p.src[p.pos:p.pos+lineEnd], p.pos+lineEnd+1
+			}
+			if h.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delim {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+		if shellReadsStdin(h.owner) {
+			p.out = append(p.out, parseShellDepth(body.String(), p.depth+1)...)
+		}
+	}
+	p.pending = nil
+}
+
+// readWord consumes one word and returns it with quotes and escapes removed.
+// Substitutions inside the word are kept verbatim in the result and their
+// commands are collected into p.nested.
+func (p *shellParser) readWord() string {
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case ' ', '\t', '\r', '\n', ';', '&', '|', '(', ')', '<', '>':
+			return b.String()
+		case '\\':
+			if p.hasPrefix("\\\n") {
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if p.pos < len(p.src) {
+				b.WriteByte(p.src[p.pos])
+				p.pos++
+			}
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				b.WriteString(p.src[p.pos+1:])
+				p.pos = len(p.src)
+				return b.String()
+			}
+			b.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+		case '"':
+			p.pos++
+			p.readDoubleQuoted(&b)
+		case '$':
+			b.WriteString(p.readDollar())
+		case '`':
+			b.WriteString(p.readBackticks())
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return b.String()
+}
+
+// readDoubleQuoted consumes the rest of a "..." string (the opening quote is
+// already consumed) into b.
+func (p *shellParser) readDoubleQuoted(b *strings.Builder) {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return
+		case '\\':
+			if p.pos+1 < len(p.src) && strings.IndexByte("$`\"\\\n", p.src[p.pos+1]) >= 0 {
+				if p.src[p.pos+1] != '\n' {
+					b.WriteByte(p.src[p.pos+1])
+				}
+				p.pos += 2
+				continue
+			}
+			b.WriteByte(c)
+			p.pos++
+		case '$':
+			b.WriteString(p.readDollar())
+		case '`':
+			b.WriteString(p.readBackticks())
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// readDollar consumes a $ expansion and returns its source text. Command
+// substitutions are parsed; arithmetic and parameter expansions are not.
+func (p *shellParser) readDollar() string {
+	start := p.pos
+	switch {
+	case p.hasPrefix("$(("):
+		p.pos++
+		p.skipBalanced('(', ')')
+	case p.hasPrefix("$("):
+		p.pos++
+		p.readSubstitution('(', ')')
+	case p.hasPrefix("${"):
+		p.pos++
+		p.skipBalanced('{', '}')
+	default:
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// readSubstitution consumes a (...) body at the current position, parses it
+// as commands into p.nested and returns the source text consumed.
+func (p *shellParser) readSubstitution(open, closing byte) string {
+	start := p.pos
+	inner := p.skipBalanced(open, closing)
+	p.nested = append(p.nested, parseShellDepth(inner, p.depth+1)...)
+	return p.src[start:p.pos]
+}
+
+// readBackticks consumes a `...` substitution, parses it into p.nested and
+// returns its source text.
+func (p *shellParser) readBackticks() string {
+	start := p.pos
+	p.pos++
+	var inner strings.Builder
+	for p.pos < len(p.src) && p.src[p.pos] != '`' {
+		if p.src[p.pos] == '\\' && p.pos+1 < len(p.src) {
+			p.pos++
+		}
+		inner.WriteByte(p.src[p.pos])
+		p.pos++
+	}
+	if p.pos < len(p.src) {
+		p.pos++
+	}
+	p.nested = append(p.nested, parseShellDepth(inner.String(), p.depth+1)...)
+	return p.src[start:p.pos]
+}
+
+// skipBalanced consumes from an opening bracket at the current position to
+// its matching close, honoring quotes and escapes, and returns the text
+// between them.
+func (p *shellParser) skipBalanced(open, closing byte) string {
+	p.pos++ // opening bracket
+	start, depth := p.pos, 1
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '\\':
+			p.pos++
+		case '\'':
+			if end := strings.IndexByte(p.src[p.pos+1:], '\''); end >= 0 {
+				p.pos += end + 1
+			} else {
+				p.pos = len(p.src) - 1
+			}
+		case '"':
+			for p.pos++; p.pos < len(p.src) && p.src[p.pos] != '"'; p.pos++ {
+				if p.src[p.pos] == '\\' {
+					p.pos++
+				}
+			}
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				inner := p.src[start:p.pos]
+				p.pos++
+				return inner
+			}
+		}
+		p.pos++
+	}
+	p.pos = len(p.src)
+	return p.src[start:]
+}
+
+// isShellAssignment reports whether a raw word is a NAME=value assignment.
+func isShellAssignment(raw string) bool {
+	eq := strings.IndexByte(raw, '=')
+	if eq <= 0 {
+		return false
+	}
+	for i, r := range raw[:eq] {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// commandWrappers maps programs that run another command from their
+// arguments to the short options that consume a value.
+var commandWrappers = map[string]string{
+	"env":     "uCS",
+	"sudo":    "ugpChDrtTU",
+	"doas":    "uC",
+	"xargs":   "ILnPsdEa",
+	"nice":    "n",
+	"ionice":  "cnp",
+	"timeout": "sk",
+	"nohup":   "",
+	"time":    "fo",
+	"command": "",
+	"builtin": "",
+	"exec":    "a",
+	"stdbuf":  "ioe",
+	"watch":   "nd",
+}
+
+// shellPrograms are interpreters whose -c argument (or stdin) is a script.
+var shellPrograms = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// unwrapShellCommand returns the commands argv runs on its behalf: the script
+// of sh -c and eval, the targets of find -exec, and the command behind a
+// prefix wrapper — each recursively unwrapped in turn.
+func unwrapShellCommand(argv []string, depth int) []shellCommand {
+	if len(argv) == 0 || depth >= maxShellDepth {
+		return nil
+	}
+	name := filepath.Base(argv[0])
+	switch {
+	case shellPrograms[name]:
+		if script, ok := shellScriptArg(argv); ok {
+			return parseShellDepth(script, depth+1)
+		}
+		return nil
+	case name == "eval":
+		return parseShellDepth(strings.Join(argv[1:], " "), depth+1)
+	case name == "find":
+		var out []shellCommand
+		for _, inner := range findExecCommands(argv) {
+			out = append(out, shellCommand{Text: strings.Join(inner, " "), Argv: inner})
+			out = append(out, unwrapShellCommand(inner, depth+1)...)
+		}
+		return out
+	}
+	inner := wrappedArgv(name, argv)
+	if len(inner) == 0 {
+		return nil
+	}
+	cmd := shellCommand{Text: strings.Join(inner, " "), Argv: inner}
+	return append([]shellCommand{cmd}, unwrapShellCommand(inner, depth+1)...)
+}
+
+// wrappedArgv returns the command a prefix wrapper runs, skipping the
+// wrapper's own options (and, for env, assignments; for timeout, the
+// duration). It returns nil for non-wrappers and wrappers with no command.
+func wrappedArgv(name string, argv []string) []string {
+	valueOpts, ok := commandWrappers[name]
+	if !ok {
+		return nil
+	}
+	i := 1
+	for i < len(argv) {
+		arg := argv[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if name == "env" && isShellAssignment(arg) {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		if name == "command" && strings.ContainsAny(arg, "vV") {
+			// command -v only looks the program up.
+			return nil
+		}
+		i++
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+		for j := 1; j < len(arg); j++ {
+			if strings.IndexByte(valueOpts, arg[j]) >= 0 {
+				if j == len(arg)-1 {
+					i++
+				}
+				break
+			}
+		}
+	}
+	if name == "timeout" && i < len(argv) {
+		i++ // duration
+	}
+	if i >= len(argv) {
+		return nil
+	}
+	return argv[i:]
+}
+
+// shellScriptArg returns the script passed to a shell with -c.
+func shellScriptArg(argv []string) (string, bool) {
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" || !(strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+")) || len(arg) < 2 {
+			return "", false
+		}
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if strings.ContainsRune(arg[1:], 'c') {
+			if i+1 < len(argv) {
+				return argv[i+1], true
+			}
+			return "", false
+		}
+		if strings.HasSuffix(arg, "o") {
+			i++ // -o option
+		}
+	}
+	return "", false
+}
+
+// shellReadsStdin reports whether argv is a shell that takes its script from
+// stdin — no -c and no script file — so a here-document body fed to it runs.
+func shellReadsStdin(argv []string) bool {
+	if len(argv) == 0 || !shellPrograms[filepath.Base(argv[0])] {
+		return false
+	}
+	if _, ok := shellScriptArg(argv); ok {
+		return false
+	}
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			return i == len(argv)-1
+		}
+		if !strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "+") {
+			return false
+		}
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.HasSuffix(arg, "o") {
+			i++
+		}
+	}
+	return true
+}
+
+// findExecCommands returns the argv of each -exec/-execdir/-ok/-okdir action
+// in a find invocation.
+func findExecCommands(argv []string) [][]string {
+	var out [][]string
+	for i := 1; i < len(argv); i++ {
+		switch argv[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+		default:
+			continue
+		}
+		j := i + 1
+		for j < len(argv) && argv[j] != ";" && argv[j] != "+" {
+			j++
+		}
+		if j > i+1 {
+			out = append(out, argv[i+1:j])
+		}
+		i = j
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package hooks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShellCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string // Text of each command, in order
+	}{
+		{"simple", "go test ./...", []string{"go test ./..."}},
+		{"list and pipeline operators", "a && b ; c | d || e & f |& g", []string{"a", "b", "c", "d", "e", "f", "g"}},
+		{"quoted operators stay in the word", `echo "a && b" 'c | d'`, []string{`echo "a && b" 'c | d'`}},
+		{"escaped operator", `echo a \; b`, []string{`echo a \; b`}},
+		{"redirections kept in text", "go test ./... 2>&1 > out.log", []string{"go test ./... 2>&1 > out.log"}},
+		{"command substitution", "git log $(git rev-parse HEAD)", []string{"git log $(git rev-parse HEAD)", "git rev-parse HEAD"}},
+		{"nested substitution in quotes", `echo "$(cat $(ls x))"`, []string{`echo "$(cat $(ls x))"`, "cat $(ls x)", "ls x"}},
+		{"backticks", "echo `whoami`", []string{"echo `whoami`", "whoami"}},
+		{"arithmetic is not a command", "echo $((1+2))", []string{"echo $((1+2))"}},
+		{"parameter expansion with braces", "echo ${HOME:-/tmp}", []string{"echo ${HOME:-/tmp}"}},
+		{"process substitution", "diff <(ls a) <(ls b)", []string{"diff <(ls a) <(ls b)", "ls a", "ls b"}},
+		{"subshell and group", "(cd x && make) ; { rm y; }", []string{"cd x", "make", "rm y"}},
+		{"assignments are skipped", "FOO=1 BAR=2 go test", []string{"FOO=1 BAR=2 go test"}},
+		{"bare assignment is not a command", "FOO=1", nil},
+		{"reserved words", "if test -f x; then rm x; fi", []string{"test -f x", "rm x"}},
+		{"for header skipped", "for f in a b; do rm $f; done", []string{"rm $f"}},
+		{"comments", "ls # && rm -rf /", []string{"ls"}},
+		{"line continuation", "go test \\\n  ./...", []string{"go test \\\n  ./..."}},
+		{"heredoc body skipped", "cat <<EOF > out\nrm -rf /\nEOF\nls", []string{"cat <<EOF > out", "ls"}},
+		{"heredoc fed to a shell", "bash <<'EOF'\nrm -rf /\nEOF", []string{"bash <<'EOF'", "rm -rf /"}},
+		{"indented heredoc", "sh <<-EOF\n\tmake\n\tEOF", []string{"sh <<-EOF", "make"}},
+		{"bash -c", `cd x && bash -c "rm -rf y"`, []string{"cd x", `bash -c "rm -rf y"`, "rm -rf y"}},
+		{"sh -ec with compound script", `sh -ec 'a && b'`, []string{`sh -ec 'a && b'`, "a", "b"}},
+		{"shell script file is not unwrapped", "bash script.sh", []string{"bash script.sh"}},
+		{"eval", `eval "rm -rf x"`, []string{`eval "rm -rf x"`, "rm -rf x"}},
+		{"sudo", "sudo -u root rm -rf /", []string{"sudo -u root rm -rf /", "rm -rf /"}},
+		{"env with assignments", "env -i FOO=1 make", []string{"env -i FOO=1 make", "make"}},
+		{"stacked wrappers", "sudo env FOO=1 nice -n 5 make", []string{"sudo env FOO=1 nice -n 5 make", "env FOO=1 nice -n 5 make", "nice -n 5 make", "make"}},
+		{"timeout skips duration", "timeout -s KILL 10s go test", []string{"timeout -s KILL 10s go test", "go test"}},
+		{"xargs", "find . -name '*.tmp' | xargs -0 -I{} rm {}", []string{"find . -name '*.tmp'", "xargs -0 -I{} rm {}", "rm {}"}},
+		{"xargs into sh -c", `xargs sh -c 'rm "$1"' _`, []string{`xargs sh -c 'rm "$1"' _`, `sh -c rm "$1" _`, `rm "$1"`}},
+		{"xargs without command", "ls | xargs", []string{"ls", "xargs"}},
+		{"command -v is a lookup", "command -v go", []string{"command -v go"}},
+		{"find -exec", `find . -name x -exec rm -f {} \;`, []string{`find . -name x -exec rm -f {} \;`, "rm -f {}"}},
+		{"unterminated quote", `echo "oops && rm`, []string{`echo "oops && rm`}},
+		{"unterminated substitution", "echo $(ls", []string{"echo $(ls", "ls"}},
+		{"empty", "   ", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, c := range parseShellCommands(tc.src) {
+				got = append(got, c.Text)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("parseShellCommands(%q)\n got %q\nwant %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseShellCommandsArgv(t *testing.T) {
+	got := parseShellCommands(`FOO=1 git commit -m "fix: a && b" 2>/dev/null`)
+	if len(got) != 1 {
+		t.Fatalf("got %d commands, want 1: %+v", len(got), got)
+	}
+	want := []string{"git", "commit", "-m", "fix: a && b"}
+	if !reflect.DeepEqual(got[0].Argv, want) {
+		t.Fatalf("argv = %q, want %q", got[0].Argv, want)
+	}
+	if strs := shellCommandStrings(got[0]); len(strs) != 2 || strs[1] != "git commit -m fix: a && b" {
+		t.Fatalf("match strings = %q", strs)
+	}
+}
+
+func TestParseShellCommandsDepthLimit(t *testing.T) {
+	src := strings.Repeat("eval ", 50) + "rm -rf x"
+	if got := parseShellCommands(src); len(got) > maxShellDepth+2 {
+		t.Fatalf("got %d commands; nesting should stop at depth %d", len(got), maxShellDepth)
+	}
+}
@@ -0,0 +1,275 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/grovetools/core/config"
+	"github.com/grovetools/core/logging"
+	"github.com/grovetools/core/pkg/models"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// Stop gates: [[hooks.stop_gate]] commands run synchronously in the Stop
+// hook. When one fails, the hook answers {"decision":"block","reason":...}
+// and Claude keeps working with the command's output as its next
+// instruction, instead of being rewoken after the fact by stop-async.
+//
+//	[[hooks.stop_gate]]
+//	name = "tests"
+//	command = "go test ./..."
+//	timeout = 300      # seconds (default 300)
+//	max_blocks = 3     # consecutive blocks before letting the agent stop (default 3)
+//
+// Gates run in parallel in the session's working directory. A gate that has
+// blocked max_blocks times in a row stops blocking, so a check the agent
+// cannot fix does not loop forever. The consecutive count lives in
+// stop_gate_state.json in the per-session hooks state dir and is reset
+// whenever Claude Code reports stop_hook_active=false, i.e. the stop was not
+// itself a continuation forced by a stop hook. Without a session id a
+// continuation stop is never blocked again. `grove hooks disable <name>`
+// skips a gate.
+
+const (
+	defaultStopGateTimeout   = 300
+	defaultStopGateMaxBlocks = 3
+	stopGateStateFile        = "stop_gate_state.json"
+	// maxStopGateOutputLines caps how much of a failing gate's output is
+	// returned to Claude as the block reason.
+	maxStopGateOutputLines = 40
+)
+
+// StopGate is one [[hooks.stop_gate]] entry.
+type StopGate struct {
+	Name      string `yaml:"name"`
+	Command   string `yaml:"command"`
+	Timeout   int    `yaml:"timeout"`
+	MaxBlocks int    `yaml:"max_blocks"`
+}
+
+// stopGateResult is the outcome of one gate run.
+type stopGateResult struct {
+	Gate     StopGate
+	Skipped  bool
+	Failed   bool
+	TimedOut bool
+	ExitCode int
+	Output   string
+}
+
+// label identifies the gate in the state file and in messages.
+func (g StopGate) label() string {
+	return ruleLabel(g.Name, g.Command)
+}
+
+// loadStopGates reads [[hooks.stop_gate]] from the grove.toml governing
+// workingDir.
+func loadStopGates(workingDir string) []StopGate {
+	if workingDir == "" {
+		return nil
+	}
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return nil
+	}
+	var hooksConfig struct {
+		StopGate []StopGate `yaml:"stop_gate"`
+	}
+	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
+		return nil
+	}
+	return hooksConfig.StopGate
+}
+
+// runStopGate executes one gate and captures its combined output. A gate
+// disabled by a marker for workingDir or sessionID is skipped.
+func runStopGate(gate StopGate, workingDir, sessionID string) stopGateResult {
+	result := stopGateResult{Gate: gate}
+	if gate.Command == "" {
+		result.Skipped = true
+		return result
+	}
+	if _, disabled := FindHookMarker(workingDir, sessionID, gate.Name); disabled {
+		result.Skipped = true
+		return result
+	}
+
+	timeout := gate.Timeout
+	if timeout <= 0 {
+		timeout = defaultStopGateTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", gate.Command) //nolint:gosec // command from trusted config
+	cmd.Dir = workingDir
+	cmd.WaitDelay = 5 * time.Second
+	output, err := cmd.CombinedOutput()
+	result.Output = string(output)
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Failed = true
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.Failed = true
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.Failed = true
+		result.ExitCode = -1
+		result.Output = err.Error()
+	}
+	return result
+}
+
+// evaluateStopGates runs the gates and returns the block reason, or "" when
+// the agent may stop. stopHookActive is Claude Code's stop_hook_active flag.
+func evaluateStopGates(sessionID string, gates []StopGate, workingDir string, stopHookActive bool) string {
+	if len(gates) == 0 {
+		return ""
+	}
+	if sessionID == "" && stopHookActive {
+		return ""
+	}
+
+	results := make([]stopGateResult, len(gates))
+	var wg sync.WaitGroup
+	for i, gate := range gates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runStopGate(gate, workingDir, sessionID)
+		}()
+	}
+	wg.Wait()
+
+	var blocking []string
+	update := func(state map[string]int) {
+		if !stopHookActive {
+			clear(state)
+		}
+		for _, r := range results {
+			key := r.Gate.label()
+			if r.Skipped || !r.Failed {
+				delete(state, key)
+				continue
+			}
+			maxBlocks := r.Gate.MaxBlocks
+			if maxBlocks <= 0 {
+				maxBlocks = defaultStopGateMaxBlocks
+			}
+			if state[key] >= maxBlocks {
+				logging.NewLogger("hooks.stop").WithFields(logrus.Fields{
+					"gate":       key,
+					"max_blocks": maxBlocks,
+				}).Warn("Stop gate still failing after max_blocks; letting the agent stop")
+				continue
+			}
+			state[key]++
+			blocking = append(blocking, formatStopGateFailure(r, state[key], maxBlocks))
+		}
+	}
+	if sessionID == "" {
+		update(map[string]int{})
+	} else {
+		updateStopGateState(sessionID, update)
+	}
+
+	if len(blocking) == 0 {
+		return ""
+	}
+	return strings.Join(blocking, "\n\n")
+}
+
+// formatStopGateFailure renders one failing gate as part of a block reason.
+func formatStopGateFailure(r stopGateResult, block, maxBlocks int) string {
+	header := fmt.Sprintf("grove.toml stop_gate %s failed (exit %d)", r.Gate.label(), r.ExitCode)
+	if r.TimedOut {
+		header = fmt.Sprintf("grove.toml stop_gate %s timed out", r.Gate.label())
+	}
+	header += fmt.Sprintf(" — fix it before stopping (block %d of %d)", block, maxBlocks)
+
+	lines := strings.Split(strings.TrimRight(r.Output, "\n"), "\n")
+	if len(lines) > maxStopGateOutputLines {
+		lines = append([]string{fmt.Sprintf("… (%d earlier lines omitted)", len(lines)-maxStopGateOutputLines)}, lines[len(lines)-maxStopGateOutputLines:]...)
+	}
+	output := strings.TrimSpace(strings.Join(lines, "\n"))
+	if output == "" {
+		return header
+	}
+	return header + ":\n" + output
+}
+
+// updateStopGateState applies fn to the session's consecutive-block counts
+// under an exclusive lock.
+func updateStopGateState(sessionID string, fn func(map[string]int)) {
+	state := map[string]int{}
+	dir := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fn(state)
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(dir, stopGateStateFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		fn(state)
+		return
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err == nil {
+		defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	}
+
+	if content, err := os.ReadFile(f.Name()); err == nil && len(content) > 0 {
+		if err := json.Unmarshal(content, &state); err != nil {
+			state = map[string]int{}
+		}
+	}
+	fn(state)
+
+	out, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt(out, 0)
+	}
+}
+
+// runStopGatesForInput evaluates the stop gates for a Stop payload and, when
+// one blocks, writes the block decision to stdout. Reports whether the stop
+// was blocked.
+func runStopGatesForInput(ctx *HookContext, rawInput []byte) bool {
+	var data StopInput
+	if err := json.Unmarshal(rawInput, &data); err != nil {
+		return false
+	}
+	workingDir := resolveAsyncWorkingDir(data)
+	reason := evaluateStopGates(data.SessionID, loadStopGates(workingDir), workingDir, data.StopHookActive)
+	if reason == "" {
+		return false
+	}
+	payload, err := json.Marshal(StopResponse{Decision: "block", Reason: reason})
+	if err != nil {
+		return false
+	}
+	if err := ctx.LogEvent(models.EventType("stop_gate_blocked"), map[string]any{
+		"stop_hook_active": data.StopHookActive,
+		"reason":           reason,
+	}); err != nil {
+		logging.NewLogger("hooks.stop").WithError(err).Debug("Failed to log stop_gate_blocked event")
+	}
+	fmt.Print(string(payload))
+	return true
+}
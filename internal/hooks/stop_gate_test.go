@@ -0,0 +1,104 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateStopGatesMaxBlocks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	gates := []StopGate{
+		{Name: "tests", Command: "echo '--- FAIL: TestX'; exit 1", MaxBlocks: 2},
+		{Name: "lint", Command: "true"},
+	}
+
+	// A fresh stop blocks, and continuations keep blocking up to max_blocks.
+	for i, active := range []bool{false, true} {
+		reason := evaluateStopGates("sess-gate", gates, work, active)
+		if !strings.Contains(reason, `stop_gate tests failed (exit 1)`) || !strings.Contains(reason, "--- FAIL: TestX") {
+			t.Fatalf("stop %d not blocked: %q", i, reason)
+		}
+		if want := fmt.Sprintf("block %d of 2", i+1); !strings.Contains(reason, want) {
+			t.Fatalf("stop %d reason %q missing %q", i, reason, want)
+		}
+		if strings.Contains(reason, "lint") {
+			t.Fatalf("passing gate reported: %q", reason)
+		}
+	}
+
+	// The guard lets the agent stop once max_blocks is reached.
+	if reason := evaluateStopGates("sess-gate", gates, work, true); reason != "" {
+		t.Fatalf("expected max_blocks guard to allow stop, got %q", reason)
+	}
+
+	// A stop that is not a continuation resets the count.
+	if reason := evaluateStopGates("sess-gate", gates, work, false); !strings.Contains(reason, "block 1 of 2") {
+		t.Fatalf("count not reset on fresh stop: %q", reason)
+	}
+}
+
+func TestEvaluateStopGatesWithoutSession(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+	gates := []StopGate{{Name: "fail", Command: "exit 3"}}
+
+	if reason := evaluateStopGates("", gates, work, false); !strings.Contains(reason, "exit 3") {
+		t.Fatalf("fresh stop not blocked: %q", reason)
+	}
+	if reason := evaluateStopGates("", gates, work, true); reason != "" {
+		t.Fatalf("continuation without session state must not block: %q", reason)
+	}
+}
+
+func TestRunStopGateTimeoutAndSkip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	r := runStopGate(StopGate{Name: "slow", Command: "sleep 5", Timeout: 1}, work, "")
+	if !r.Failed || !r.TimedOut {
+		t.Fatalf("expected timeout, got %+v", r)
+	}
+	if got := formatStopGateFailure(r, 1, 3); !strings.Contains(got, "slow timed out") {
+		t.Fatalf("timeout message = %q", got)
+	}
+
+	if err := DisableHook(work, "off", ""); err != nil {
+		t.Fatal(err)
+	}
+	if r := runStopGate(StopGate{Name: "off", Command: "exit 1"}, work, ""); !r.Skipped || r.Failed {
+		t.Fatalf("disabled gate ran: %+v", r)
+	}
+
+	// A session marker skips the gate for that session only.
+	registerTestSession(t, "sess-gate")
+	if err := DisableHookInScope(work, "sess-gate", "mine", "", DisableSession, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if r := runStopGate(StopGate{Name: "mine", Command: "exit 1"}, work, "sess-gate"); !r.Skipped {
+		t.Fatalf("session-disabled gate ran: %+v", r)
+	}
+	if r := runStopGate(StopGate{Name: "mine", Command: "exit 1"}, work, "other"); r.Skipped || !r.Failed {
+		t.Fatalf("gate skipped for another session: %+v", r)
+	}
+	if reason := evaluateStopGates("sess-gate", []StopGate{{Name: "mine", Command: "exit 1"}}, work, false); reason != "" {
+		t.Fatalf("session-disabled gate blocked: %q", reason)
+	}
+}
+
+func TestFormatStopGateFailureTruncates(t *testing.T) {
+	var out strings.Builder
+	for i := 1; i <= maxStopGateOutputLines+10; i++ {
+		fmt.Fprintf(&out, "line %d\n", i)
+	}
+	got := formatStopGateFailure(stopGateResult{Gate: StopGate{Name: "big"}, Failed: true, ExitCode: 1, Output: out.String()}, 1, 3)
+	if !strings.Contains(got, "(10 earlier lines omitted)") || strings.Contains(got, "line 10\n") || !strings.HasSuffix(got, fmt.Sprintf("line %d", maxStopGateOutputLines+10)) {
+		t.Fatalf("unexpected truncation:\n%s", got)
+	}
+}
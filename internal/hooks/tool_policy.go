@@ -0,0 +1,173 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grovetools/core/config"
+)
+
+// PreToolUse policy: repo-scoped permission decisions declared in grove.toml.
+//
+//	[[hooks.pre_tool_use]]
+//	name = "no-force-push"
+//	if = "Bash(git push --force *)"
+//	action = "deny"
+//	reason = "Force pushes are blocked in this repo; open a PR instead."
+//
+// Each entry pairs a permission rule (the same syntax the post_tool_use `if`
+// field uses, evaluated by evaluatePermissionRule) with the decision to return
+// to Claude Code when it matches. The decision is emitted as
+// hookSpecificOutput.permissionDecision, so a team can centrally block or
+// gate tool calls per repo instead of relying on each engineer's
+// settings.json.
+//
+// An entry may instead name a validator `command` (see tool_validators.go)
+// that receives the PreToolUse payload on stdin and decides by exit code.
+
+// Permission decisions a PreToolUse hook can return to Claude Code.
+const (
+	permissionAllow = "allow"
+	permissionAsk   = "ask"
+	permissionDeny  = "deny"
+)
+
+// permissionRank orders decisions by precedence when several rules match the
+// same tool call. Mirrors Claude Code's own rule evaluation: deny beats ask,
+// ask beats allow.
+var permissionRank = map[string]int{
+	permissionAllow: 1,
+	permissionAsk:   2,
+	permissionDeny:  3,
+}
+
+// PreToolUseRule is one [[hooks.pre_tool_use]] entry from grove.toml. A
+// static rule sets If and Action; a validator sets Command, with If as an
+// optional filter and Action as the decision for a blocking exit (default
+// deny).
+type PreToolUseRule struct {
+	Name   string `yaml:"name"`
+	If     string `yaml:"if"`
+	Action string `yaml:"action"` // deny | ask | allow
+	Reason string `yaml:"reason"`
+
+	// Validator settings. Timeout is in seconds (default
+	// defaultValidatorTimeout); FailClosed denies the tool call when the
+	// validator itself errors or times out instead of letting it through.
+	Command    string `yaml:"command"`
+	Timeout    int    `yaml:"timeout"`
+	FailClosed bool   `yaml:"fail_closed"`
+}
+
+// preToolUseDecision is the outcome of evaluating the policy for one tool
+// call: the winning action, the reason shown to Claude (deny) or the user
+// (ask/allow), and the name of the rule that produced it.
+type preToolUseDecision struct {
+	Action string
+	Reason string
+	Rule   string
+}
+
+// preToolUseConfig is the PreToolUse section of the hooks config: permission
+// rules and validators, plus Bash rewrites (see tool_rewrite.go).
+type preToolUseConfig struct {
+	Rules       []PreToolUseRule  `yaml:"pre_tool_use"`
+	BashRewrite []BashRewriteRule `yaml:"bash_rewrite"`
+}
+
+// loadPreToolUseConfig reads the [[hooks.pre_tool_use]] and
+// [[hooks.bash_rewrite]] entries from the grove.toml governing workingDir. A
+// missing or unreadable config yields no rules — the policy never blocks a
+// tool call because of a config error.
+func loadPreToolUseConfig(workingDir string) preToolUseConfig {
+	var hooksConfig preToolUseConfig
+	if workingDir == "" {
+		return hooksConfig
+	}
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return hooksConfig
+	}
+	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
+		return preToolUseConfig{}
+	}
+	return hooksConfig
+}
+
+// evaluatePreToolUsePolicy returns the decision for a tool call against the
+// configured static rules. When several rules match, the highest-precedence
+// action wins (deny > ask > allow); among rules with the same action the
+// first declared wins. Validator entries, entries with an empty `if` and
+// entries with an unknown action are ignored. ok=false means no rule matched
+// and the normal permission flow applies.
+func evaluatePreToolUsePolicy(rules []PreToolUseRule, toolName string, toolInput map[string]any, workingDir string) (preToolUseDecision, bool) {
+	var (
+		best  preToolUseDecision
+		found bool
+	)
+	for _, rule := range rules {
+		action := strings.ToLower(strings.TrimSpace(rule.Action))
+		if rule.Command != "" || rule.If == "" || permissionRank[action] == 0 {
+			continue
+		}
+		if !evaluatePermissionRule(rule.If, toolName, toolInput, workingDir) {
+			continue
+		}
+		if found && !outranks(action, best.Action) {
+			continue
+		}
+		best = preToolUseDecision{
+			Action: action,
+			Reason: policyReason(rule, action),
+			Rule:   ruleLabel(rule.Name, rule.If),
+		}
+		found = true
+	}
+	return best, found
+}
+
+// outranks reports whether decision a takes precedence over decision b.
+func outranks(a, b string) bool {
+	return permissionRank[a] > permissionRank[b]
+}
+
+// policyReason returns the configured reason, or a default naming the rule
+// so a bare `action = "deny"` entry still tells the agent why it was blocked.
+func policyReason(rule PreToolUseRule, action string) string {
+	if reason := strings.TrimSpace(rule.Reason); reason != "" {
+		return reason
+	}
+	label := ruleLabel(rule.Name, rule.If)
+	switch action {
+	case permissionDeny:
+		return fmt.Sprintf("Blocked by grove.toml pre_tool_use rule %q", label)
+	case permissionAsk:
+		return fmt.Sprintf("grove.toml pre_tool_use rule %q requires confirmation", label)
+	default:
+		return fmt.Sprintf("Allowed by grove.toml pre_tool_use rule %q", label)
+	}
+}
+
+// ruleLabel identifies a configured entry in logs and reasons: its name when
+// set, otherwise its `if` rule.
+func ruleLabel(name, rule string) string {
+	if name != "" {
+		return name
+	}
+	return rule
+}
+
+// newPreToolUseResponse converts a policy decision into the hook response.
+// Approved stays true for ask/allow (the tool may still run); only deny
+// clears it, which also suppresses the tool-execution record.
+func newPreToolUseResponse(decision preToolUseDecision) PreToolUseResponse {
+	return PreToolUseResponse{
+		Approved: decision.Action != permissionDeny,
+		Message:  decision.Reason,
+		HookSpecificOutput: &PreToolUseHookOutput{
+			HookEventName:            "PreToolUse",
+			PermissionDecision:       decision.Action,
+			PermissionDecisionReason: decision.Reason,
+		},
+	}
+}
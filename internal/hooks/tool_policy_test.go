@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEvaluatePreToolUsePolicy(t *testing.T) {
+	rules := []PreToolUseRule{
+		{Name: "allow-git", If: "Bash(git *)", Action: "allow"},
+		{Name: "ask-push", If: "Bash(git push *)", Action: "ask"},
+		{Name: "no-force-push", If: "Bash(git push --force *)", Action: "deny", Reason: "force pushes are blocked"},
+		{Name: "no-env", If: "Write(*.env)", Action: "DENY"},
+		{Name: "bogus-action", If: "Bash(ls *)", Action: "maybe"},
+		{Name: "no-if", Action: "deny"},
+	}
+
+	tests := []struct {
+		name       string
+		toolName   string
+		input      map[string]any
+		wantOK     bool
+		wantAction string
+		wantRule   string
+	}{
+		{"deny beats ask and allow", "Bash", map[string]any{"command": "git push --force origin main"}, true, permissionDeny, "no-force-push"},
+		{"ask beats allow", "Bash", map[string]any{"command": "git push origin main"}, true, permissionAsk, "ask-push"},
+		{"allow only", "Bash", map[string]any{"command": "git status -s"}, true, permissionAllow, "allow-git"},
+		{"action is case-insensitive", "Write", map[string]any{"file_path": "/repo/.env"}, true, permissionDeny, "no-env"},
+		{"unknown action ignored", "Bash", map[string]any{"command": "ls -la"}, false, "", ""},
+		{"no match", "Read", map[string]any{"file_path": "/repo/main.go"}, false, "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := evaluatePreToolUsePolicy(rules, tc.toolName, tc.input, "")
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v (decision %+v)", ok, tc.wantOK, got)
+			}
+			if got.Action != tc.wantAction || got.Rule != tc.wantRule {
+				t.Fatalf("decision = %+v, want action %q rule %q", got, tc.wantAction, tc.wantRule)
+			}
+		})
+	}
+}
+
+func TestPolicyReasonDefaults(t *testing.T) {
+	got, _ := evaluatePreToolUsePolicy([]PreToolUseRule{
+		{If: "Write(*.env)", Action: "deny"},
+	}, "Write", map[string]any{"file_path": "prod.env"}, "")
+	if !strings.Contains(got.Reason, "Write(*.env)") {
+		t.Fatalf("default reason %q should name the rule", got.Reason)
+	}
+
+	got, _ = evaluatePreToolUsePolicy([]PreToolUseRule{
+		{Name: "no-force-push", If: "Bash(git push --force *)", Action: "deny", Reason: "  use a PR  "},
+	}, "Bash", map[string]any{"command": "git push --force origin"}, "")
+	if got.Reason != "use a PR" {
+		t.Fatalf("reason = %q, want trimmed configured reason", got.Reason)
+	}
+}
+
+func TestNewPreToolUseResponse(t *testing.T) {
+	resp := newPreToolUseResponse(preToolUseDecision{Action: permissionDeny, Reason: "nope", Rule: "r"})
+	if resp.Approved {
+		t.Fatal("deny must not be approved")
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	out, ok := decoded["hookSpecificOutput"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing hookSpecificOutput in %s", b)
+	}
+	if out["hookEventName"] != "PreToolUse" || out["permissionDecision"] != "deny" || out["permissionDecisionReason"] != "nope" {
+		t.Fatalf("unexpected hookSpecificOutput %v", out)
+	}
+
+	if resp := newPreToolUseResponse(preToolUseDecision{Action: permissionAsk}); !resp.Approved {
+		t.Fatal("ask must stay approved so the tool can still run after confirmation")
+	}
+
+	// No matching rule: no permission decision is emitted at all.
+	b, _ = json.Marshal(PreToolUseResponse{Approved: true})
+	if strings.Contains(string(b), "hookSpecificOutput") {
+		t.Fatalf("approve-all response must not carry hookSpecificOutput: %s", b)
+	}
+}
+
+func TestLoadPreToolUseRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := t.TempDir()
+	toml := `name = "demo"
+
+[[hooks.pre_tool_use]]
+name = "no-force-push"
+if = "Bash(git push --force *)"
+action = "deny"
+reason = "blocked"
+`
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := loadPreToolUseConfig(repo).Rules
+	if len(rules) != 1 {
+		t.Fatalf("got %d rules, want 1: %+v", len(rules), rules)
+	}
+	want := PreToolUseRule{Name: "no-force-push", If: "Bash(git push --force *)", Action: "deny", Reason: "blocked"}
+	if rules[0] != want {
+		t.Fatalf("rule = %+v, want %+v", rules[0], want)
+	}
+
+	resp := validateTool("Bash", map[string]any{"command": "git push --force origin"}, nil, repo)
+	if resp.Approved || resp.HookSpecificOutput == nil || resp.HookSpecificOutput.PermissionDecision != permissionDeny {
+		t.Fatalf("validateTool = %+v, want deny", resp)
+	}
+	if resp := validateTool("Bash", map[string]any{"command": "git status"}, nil, repo); !resp.Approved || resp.HookSpecificOutput != nil {
+		t.Fatalf("validateTool = %+v, want plain approval", resp)
+	}
+}
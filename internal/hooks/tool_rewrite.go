@@ -0,0 +1,134 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grovetools/core/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// Bash rewrites: grove.toml rules that modify a Bash command before it runs,
+// returned to Claude Code as hookSpecificOutput.updatedInput.
+//
+//	[[hooks.bash_rewrite]]
+//	name = "go-test-no-cache"
+//	match = '\bgo test\b'
+//	replace = "go test -count=1"
+//	unless = '-count='
+//
+//	[[hooks.bash_rewrite]]
+//	name = "pnpm"
+//	match = '\bnpm\b'
+//	replace = "pnpm"
+//	action = "allow"
+//
+// Match is a Go regexp applied to the whole command string and Replace is its
+// expansion template ($1, ${name}, $0 for the whole match). Rules apply in
+// declared order, each to the output of the previous one. If (a permission
+// rule) and Unless (a regexp) optionally restrict which commands a rule
+// touches.
+//
+// Claude Code only honors updatedInput alongside a permission decision, so
+// every rewrite carries one. By default it is "ask": the rewritten command is
+// shown for confirmation. A rule with action = "allow" runs it without
+// prompting, but only when the rewritten command is a single program; a
+// compound command (pipes, &&, ;, substitutions, sh -c) still asks, since the
+// rule's regexp says nothing about the other programs. A pre_tool_use rule
+// that allows the rewritten command approves it either way.
+
+// BashRewriteRule is one [[hooks.bash_rewrite]] entry from grove.toml.
+type BashRewriteRule struct {
+	Name    string `yaml:"name"`
+	If      string `yaml:"if"`
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+	Unless  string `yaml:"unless"`
+	Action  string `yaml:"action"` // ask | allow
+}
+
+// applyBashRewrites runs the configured rewrite rules over a Bash tool input.
+// It returns the rewritten input (a copy — toolInput is never mutated), the
+// decision to pair with it, and whether the command changed. Rules with an
+// invalid regexp are skipped and logged.
+func applyBashRewrites(rules []BashRewriteRule, toolName string, toolInput map[string]any, workingDir string) (map[string]any, preToolUseDecision, bool) {
+	original, ok := extractBashCommand(toolInput)
+	if toolName != "Bash" || !ok || len(rules) == 0 {
+		return toolInput, preToolUseDecision{}, false
+	}
+
+	slog := logging.NewLogger("hooks.pretooluse")
+	command := original
+	action := permissionAllow
+	var applied []string
+	for _, rule := range rules {
+		if rule.Match == "" {
+			continue
+		}
+		if rule.If != "" && !evaluatePermissionRule(rule.If, toolName, map[string]any{"command": command}, workingDir) {
+			continue
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			slog.WithFields(logrus.Fields{"rule": rule.Name, "error": err.Error()}).Warn("Invalid bash_rewrite match pattern")
+			continue
+		}
+		if rule.Unless != "" {
+			unless, err := regexp.Compile(rule.Unless)
+			if err != nil {
+				slog.WithFields(logrus.Fields{"rule": rule.Name, "error": err.Error()}).Warn("Invalid bash_rewrite unless pattern")
+				continue
+			}
+			if unless.MatchString(command) {
+				continue
+			}
+		}
+		rewritten := re.ReplaceAllString(command, rule.Replace)
+		if rewritten == command {
+			continue
+		}
+		command = rewritten
+		applied = append(applied, ruleLabel(rule.Name, rule.Match))
+		// Any rule that did not opt into allow makes the whole rewrite ask.
+		if strings.ToLower(strings.TrimSpace(rule.Action)) != permissionAllow {
+			action = permissionAsk
+		}
+	}
+	if command == original {
+		return toolInput, preToolUseDecision{}, false
+	}
+	if action == permissionAllow && len(parseShellCommands(command)) != 1 {
+		action = permissionAsk
+	}
+
+	updated := make(map[string]any, len(toolInput))
+	for k, v := range toolInput {
+		updated[k] = v
+	}
+	updated["command"] = command
+
+	label := strings.Join(applied, ", ")
+	return updated, preToolUseDecision{
+		Action: action,
+		Reason: fmt.Sprintf("grove.toml bash_rewrite %s: %q -> %q", label, original, command),
+		Rule:   label,
+	}, true
+}
+
+// withToolInput returns payload with its tool_input replaced, so validators
+// see the rewritten command. The payload is returned unchanged when it is not
+// a JSON object.
+func withToolInput(payload []byte, toolInput map[string]any) []byte {
+	var decoded map[string]any
+	if err := json.Unmarshal(payload, &decoded); err != nil || decoded == nil {
+		return payload
+	}
+	decoded["tool_input"] = toolInput
+	out, err := json.Marshal(decoded)
+	if err != nil {
+		return payload
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyBashRewrites(t *testing.T) {
+	rules := []BashRewriteRule{
+		{Name: "no-cache", Match: `\bgo test\b`, Replace: "go test -count=1", Unless: `-count=`, Action: "allow"},
+		{Name: "pnpm", If: "Bash(npm *)", Match: `^npm\b`, Replace: "pnpm", Action: "allow"},
+		{Name: "nice", Match: `^make\b`, Replace: "nice -n 10 $0"},
+		{Name: "broken", Match: `(`, Replace: "x"},
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		wantChanged bool
+		wantCommand string
+		wantAction  string
+	}{
+		{"adds flag", "go test ./...", true, "go test -count=1 ./...", permissionAllow},
+		{"compound command asks despite allow", "cd x && go test ./...", true, "cd x && go test -count=1 ./...", permissionAsk},
+		{"substitution asks despite allow", "go test $(go list ./...)", true, "go test -count=1 $(go list ./...)", permissionAsk},
+		{"unless skips already-flagged command", "go test -count=1 ./...", false, "go test -count=1 ./...", ""},
+		{"if filter gates rule", "npm install", true, "pnpm install", permissionAllow},
+		{"word boundary leaves pnpm alone", "pnpm install", false, "pnpm install", ""},
+		{"template expands whole match, default action asks", "make build", true, "nice -n 10 make build", permissionAsk},
+		{"no rule matches", "ls -la", false, "ls -la", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := map[string]any{"command": tc.command, "description": "d"}
+			got, decision, changed := applyBashRewrites(rules, "Bash", input, "")
+			if changed != tc.wantChanged {
+				t.Fatalf("changed = %v, want %v", changed, tc.wantChanged)
+			}
+			if got["command"] != tc.wantCommand {
+				t.Fatalf("command = %q, want %q", got["command"], tc.wantCommand)
+			}
+			if decision.Action != tc.wantAction {
+				t.Fatalf("action = %q, want %q", decision.Action, tc.wantAction)
+			}
+			if got["description"] != "d" {
+				t.Fatal("other input fields must be preserved")
+			}
+			if input["command"] != tc.command {
+				t.Fatal("original input must not be mutated")
+			}
+		})
+	}
+
+	if _, _, changed := applyBashRewrites(rules, "Read", map[string]any{"command": "go test"}, ""); changed {
+		t.Fatal("only Bash inputs are rewritten")
+	}
+}
+
+func TestValidateToolRewrite(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := t.TempDir()
+	toml := `name = "demo"
+
+[[hooks.bash_rewrite]]
+name = "pnpm"
+match = '^npm\b'
+replace = "pnpm"
+action = "allow"
+
+[[hooks.pre_tool_use]]
+name = "no-pnpm-publish"
+if = "Bash(pnpm publish*)"
+action = "deny"
+`
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := validateTool("Bash", map[string]any{"command": "npm install"}, nil, repo)
+	out := resp.HookSpecificOutput
+	if out == nil || out.PermissionDecision != permissionAllow || out.UpdatedInput["command"] != "pnpm install" {
+		t.Fatalf("validateTool = %+v, want allow with rewritten command", out)
+	}
+	if !strings.Contains(out.PermissionDecisionReason, "pnpm") {
+		t.Fatalf("reason %q should name the rewrite", out.PermissionDecisionReason)
+	}
+
+	// An allow rewrite never approves the rest of a compound command.
+	resp = validateTool("Bash", map[string]any{"command": "npm i && rm -rf ~"}, nil, repo)
+	out = resp.HookSpecificOutput
+	if out == nil || out.PermissionDecision != permissionAsk || out.UpdatedInput["command"] != "pnpm i && rm -rf ~" {
+		t.Fatalf("validateTool = %+v, want ask with rewritten command", out)
+	}
+
+	// Policy judges the rewritten command, and a deny drops the rewrite.
+	resp = validateTool("Bash", map[string]any{"command": "npm publish"}, nil, repo)
+	if resp.Approved || resp.HookSpecificOutput.UpdatedInput != nil {
+		t.Fatalf("validateTool = %+v, want deny without updatedInput", resp.HookSpecificOutput)
+	}
+}
+
+func TestValidateToolRewriteApprovedByAllowRule(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+
+	repo := t.TempDir()
+	toml := `name = "demo"
+
+[[hooks.bash_rewrite]]
+name = "no-cache"
+match = '\bgo test\b'
+replace = "go test -count=1"
+
+[[hooks.pre_tool_use]]
+name = "tests"
+if = "Bash(go test -count=1 *)"
+action = "allow"
+`
+	if err := os.WriteFile(filepath.Join(repo, "grove.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := validateTool("Bash", map[string]any{"command": "go test ./..."}, nil, repo).HookSpecificOutput
+	if out == nil || out.PermissionDecision != permissionAllow || out.UpdatedInput["command"] != "go test -count=1 ./..." {
+		t.Fatalf("validateTool = %+v, want allow with rewritten command", out)
+	}
+	out = validateTool("Bash", map[string]any{"command": "go vet ./..."}, nil, repo).HookSpecificOutput
+	if out != nil {
+		t.Fatalf("validateTool = %+v, want no decision for an untouched command", out)
+	}
+}
@@ -0,0 +1,135 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/grovetools/core/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// PreToolUse validators: external commands named by a [[hooks.pre_tool_use]]
+// entry that decide a tool call by exit code, so secret scanners and path
+// allow-lists can be plugged in without rebuilding the hooks binary.
+//
+//	[[hooks.pre_tool_use]]
+//	name = "secret-scan"
+//	if = "Write(*)"                  # optional filter; omit to see every call
+//	command = "scripts/scan-secrets"
+//	timeout = 5
+//	fail_closed = true
+//
+// The validator receives the raw PreToolUse JSON payload on stdin and runs in
+// the repo working directory. Exit codes mirror ExecuteHookCommand's
+// blocking convention:
+//
+//	0      no objection — the normal permission flow applies
+//	2      blocking — stderr becomes the reason; the entry's action (default
+//	       deny) is returned to Claude Code
+//	other  validator error — ignored unless fail_closed is set, in which case
+//	       the tool call is denied
+//
+// A timeout counts as a validator error.
+
+// defaultValidatorTimeout is the per-validator timeout in seconds. Validators
+// run synchronously before every matching tool call, so it is deliberately
+// short.
+const defaultValidatorTimeout = 10
+
+// runPreToolUseValidators runs the validator entries that apply to a tool
+// call, in declared order, and returns the strongest decision they produced.
+// It stops at the first deny since nothing can outrank it. ok=false means no
+// validator objected.
+func runPreToolUseValidators(rules []PreToolUseRule, toolName string, toolInput map[string]any, payload []byte, workingDir string) (preToolUseDecision, bool) {
+	var (
+		best  preToolUseDecision
+		found bool
+	)
+	for _, rule := range rules {
+		if rule.Command == "" {
+			continue
+		}
+		if rule.If != "" && !evaluatePermissionRule(rule.If, toolName, toolInput, workingDir) {
+			continue
+		}
+		decision, ok := runPreToolUseValidator(rule, payload, workingDir)
+		if !ok {
+			continue
+		}
+		if !found || outranks(decision.Action, best.Action) {
+			best, found = decision, true
+		}
+		if best.Action == permissionDeny {
+			break
+		}
+	}
+	return best, found
+}
+
+// runPreToolUseValidator executes one validator and maps its exit status to a
+// decision. ok=false means the validator passed, or failed open.
+func runPreToolUseValidator(rule PreToolUseRule, payload []byte, workingDir string) (preToolUseDecision, bool) {
+	slog := logging.NewLogger("hooks.pretooluse")
+	label := ruleLabel(rule.Name, rule.Command)
+
+	timeout := rule.Timeout
+	if timeout <= 0 {
+		timeout = defaultValidatorTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", rule.Command) //nolint:gosec // command from trusted config
+	cmd.Dir = workingDir
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+	// A killed shell can leave grandchildren holding the stderr pipe; don't
+	// let them stretch the wait past the timeout.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err == nil {
+		return preToolUseDecision{}, false
+	}
+
+	stderrOutput := strings.TrimSpace(stderrBuf.String())
+	var exitErr *exec.ExitError
+	if ctx.Err() == nil && errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+		action := strings.ToLower(strings.TrimSpace(rule.Action))
+		if permissionRank[action] == 0 {
+			action = permissionDeny
+		}
+		reason := stderrOutput
+		if reason == "" {
+			reason = policyReason(PreToolUseRule{Name: label, Reason: rule.Reason}, action)
+		}
+		return preToolUseDecision{Action: action, Reason: reason, Rule: label}, true
+	}
+
+	failure := err.Error()
+	if ctx.Err() == context.DeadlineExceeded {
+		failure = fmt.Sprintf("timed out after %ds", timeout)
+	} else if stderrOutput != "" {
+		failure = fmt.Sprintf("%s: %s", failure, stderrOutput)
+	}
+	slog.WithFields(logrus.Fields{
+		"validator":   label,
+		"error":       failure,
+		"fail_closed": rule.FailClosed,
+	}).Warn("PreToolUse validator failed")
+
+	if !rule.FailClosed {
+		return preToolUseDecision{}, false
+	}
+	return preToolUseDecision{
+		Action: permissionDeny,
+		Reason: fmt.Sprintf("grove.toml pre_tool_use validator %q failed (%s); denying because fail_closed is set", label, failure),
+		Rule:   label,
+	}, true
+}
@@ -0,0 +1,114 @@
+package hooks
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunPreToolUseValidators(t *testing.T) {
+	payload := []byte(`{"session_id":"s1","tool_name":"Bash","tool_input":{"command":"cat .env"}}`)
+	bash := map[string]any{"command": "cat .env"}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		rules      []PreToolUseRule
+		wantOK     bool
+		wantAction string
+		wantReason string
+	}{
+		{
+			name:   "exit 0 has no objection",
+			rules:  []PreToolUseRule{{Name: "ok", Command: "cat >/dev/null; exit 0"}},
+			wantOK: false,
+		},
+		{
+			name:       "exit 2 denies with stderr as reason",
+			rules:      []PreToolUseRule{{Name: "scan", Command: `cat >/dev/null; echo "secret in .env" >&2; exit 2`}},
+			wantOK:     true,
+			wantAction: permissionDeny,
+			wantReason: "secret in .env",
+		},
+		{
+			name:       "payload arrives on stdin",
+			rules:      []PreToolUseRule{{Name: "stdin", Command: `grep -q '"tool_name":"Bash"' && exit 2; exit 0`}},
+			wantOK:     true,
+			wantAction: permissionDeny,
+		},
+		{
+			name:       "exit 2 honors configured action",
+			rules:      []PreToolUseRule{{Name: "confirm", Command: "exit 2", Action: "ask"}},
+			wantOK:     true,
+			wantAction: permissionAsk,
+			wantReason: "confirm",
+		},
+		{
+			name:   "other exit code fails open by default",
+			rules:  []PreToolUseRule{{Name: "broken", Command: "exit 1"}},
+			wantOK: false,
+		},
+		{
+			name:       "other exit code denies when fail_closed",
+			rules:      []PreToolUseRule{{Name: "broken", Command: "exit 1", FailClosed: true}},
+			wantOK:     true,
+			wantAction: permissionDeny,
+			wantReason: "fail_closed",
+		},
+		{
+			name:       "timeout denies when fail_closed",
+			rules:      []PreToolUseRule{{Name: "slow", Command: "sleep 5", Timeout: 1, FailClosed: true}},
+			wantOK:     true,
+			wantAction: permissionDeny,
+			wantReason: "timed out after 1s",
+		},
+		{
+			name:   "if filter skips non-matching tool calls",
+			rules:  []PreToolUseRule{{Name: "writes-only", If: "Write(*)", Command: "exit 2"}},
+			wantOK: false,
+		},
+		{
+			name: "deny outranks earlier ask",
+			rules: []PreToolUseRule{
+				{Name: "confirm", Command: "exit 2", Action: "ask"},
+				{Name: "block", Command: "echo blocked >&2; exit 2"},
+			},
+			wantOK:     true,
+			wantAction: permissionDeny,
+			wantReason: "blocked",
+		},
+		{
+			name:   "static rules are not validators",
+			rules:  []PreToolUseRule{{Name: "static", If: "Bash(cat *)", Action: "deny"}},
+			wantOK: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := runPreToolUseValidators(tc.rules, "Bash", bash, payload, dir)
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v (decision %+v)", ok, tc.wantOK, got)
+			}
+			if got.Action != tc.wantAction {
+				t.Fatalf("action = %q, want %q", got.Action, tc.wantAction)
+			}
+			if !strings.Contains(got.Reason, tc.wantReason) {
+				t.Fatalf("reason = %q, want it to contain %q", got.Reason, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestRunPreToolUseValidatorsStopsAtDeny(t *testing.T) {
+	marker := t.TempDir() + "/ran"
+	rules := []PreToolUseRule{
+		{Name: "block", Command: "exit 2"},
+		{Name: "after", Command: "touch " + marker},
+	}
+	if _, ok := runPreToolUseValidators(rules, "Bash", map[string]any{"command": "ls"}, nil, t.TempDir()); !ok {
+		t.Fatal("expected deny")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("validators after a deny must not run")
+	}
+}
@@ -60,6 +60,16 @@ type StopInput struct {
 	CurrentUUID    string `json:"current_uuid,omitempty"`
 	ParentUUID     string `json:"parent_uuid,omitempty"`
 	Cwd            string `json:"cwd,omitempty"`
+	// StopHookActive is set when Claude Code is already continuing because
+	// a stop hook blocked the previous stop.
+	StopHookActive bool `json:"stop_hook_active,omitempty"`
+}
+
+// StopResponse is the Stop hook output of a blocking stop_gate: Claude keeps
+// working with Reason as its next instruction.
+type StopResponse struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
 }
 
 // SessionStartInput is the payload delivered to the SessionStart hook.
@@ -72,6 +82,19 @@ type SessionStartInput struct {
 	HookEventName  string `json:"hook_event_name"`
 }
 
+// SessionStartResponse is the SessionStart hook output carrying the job
+// briefing.
+type SessionStartResponse struct {
+	HookSpecificOutput SessionStartHookOutput `json:"hookSpecificOutput"`
+}
+
+// SessionStartHookOutput is the hookSpecificOutput object of a SessionStart
+// response.
+type SessionStartHookOutput struct {
+	HookEventName     string `json:"hookEventName"`
+	AdditionalContext string `json:"additionalContext"`
+}
+
 // SubagentStartInput is the payload delivered to the SubagentStart hook.
 // Observed field set (CC v2.1.172 probe): session_id, transcript_path, cwd,
 // hook_event_name, agent_id, agent_type. The payload is minimal by design —
@@ -130,15 +153,85 @@ type SessionStatusInput struct {
 
 // SessionEndInput is the payload for the session-end hook, sent by provider
 // integrations when the provider destroyed the session (e.g. opencode's
-// session.deleted event).
+// session.deleted event) and by Claude Code's native SessionEnd event, which
+// also carries transcript_path and a reason such as "clear", "logout" or
+// "prompt_input_exit".
 type SessionEndInput struct {
-	SessionID     string `json:"session_id"`
-	HookEventName string `json:"hook_event_name"`
-	Reason        string `json:"reason,omitempty"`
-	Cwd           string `json:"cwd,omitempty"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path,omitempty"`
+	HookEventName  string `json:"hook_event_name"`
+	Reason         string `json:"reason,omitempty"`
+	Cwd            string `json:"cwd,omitempty"`
+}
+
+// PreCompactInput is the payload delivered to the PreCompact hook before
+// Claude Code compacts the conversation. Trigger is "manual" (/compact) or
+// "auto"; CustomInstructions carries the /compact argument.
+type PreCompactInput struct {
+	SessionID          string `json:"session_id"`
+	TranscriptPath     string `json:"transcript_path"`
+	Cwd                string `json:"cwd,omitempty"`
+	HookEventName      string `json:"hook_event_name"`
+	Trigger            string `json:"trigger"`
+	CustomInstructions string `json:"custom_instructions,omitempty"`
+}
+
+// PreCompactResponse is the PreCompact hook output carrying grove.toml
+// compaction instructions.
+type PreCompactResponse struct {
+	HookSpecificOutput PreCompactHookOutput `json:"hookSpecificOutput"`
+}
+
+// PreCompactHookOutput is the hookSpecificOutput object of a PreCompact
+// response.
+type PreCompactHookOutput struct {
+	HookEventName     string `json:"hookEventName"`
+	AdditionalContext string `json:"additionalContext"`
+}
+
+// UserPromptSubmitInput is the payload delivered to the UserPromptSubmit
+// hook, before Claude sees the user's prompt.
+type UserPromptSubmitInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path,omitempty"`
+	Cwd            string `json:"cwd,omitempty"`
+	HookEventName  string `json:"hook_event_name"`
+	Prompt         string `json:"prompt"`
+}
+
+// UserPromptSubmitResponse is the UserPromptSubmit hook output. Decision
+// "block" rejects the prompt and shows Reason to the user; otherwise
+// HookSpecificOutput may add context alongside the prompt.
+type UserPromptSubmitResponse struct {
+	Decision           string                      `json:"decision,omitempty"`
+	Reason             string                      `json:"reason,omitempty"`
+	HookSpecificOutput *UserPromptSubmitHookOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// UserPromptSubmitHookOutput is the hookSpecificOutput object of a
+// UserPromptSubmit response.
+type UserPromptSubmitHookOutput struct {
+	HookEventName     string `json:"hookEventName"`
+	AdditionalContext string `json:"additionalContext,omitempty"`
 }
 
 type PreToolUseResponse struct {
 	Approved bool   `json:"approved"`
 	Message  string `json:"message,omitempty"`
+	// HookSpecificOutput carries Claude Code's permission decision when a
+	// [[hooks.pre_tool_use]] rule matched. Nil means no opinion: the normal
+	// permission flow applies.
+	HookSpecificOutput *PreToolUseHookOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// PreToolUseHookOutput is the hookSpecificOutput object of a PreToolUse
+// response. PermissionDecision is one of "allow", "deny" or "ask"; the reason
+// is shown to Claude for deny and to the user for allow/ask.
+type PreToolUseHookOutput struct {
+	HookEventName            string `json:"hookEventName"`
+	PermissionDecision       string `json:"permissionDecision,omitempty"`
+	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
+	// UpdatedInput replaces the tool input before execution when a
+	// [[hooks.bash_rewrite]] rule changed the command.
+	UpdatedInput map[string]any `json:"updatedInput,omitempty"`
 }
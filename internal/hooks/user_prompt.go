@@ -0,0 +1,248 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grovetools/core/config"
+	"github.com/grovetools/core/logging"
+	"github.com/grovetools/core/pkg/models"
+	"github.com/grovetools/core/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// UserPromptSubmit policy: [[hooks.user_prompt_submit]] entries run against
+// every prompt before Claude sees it. An entry either blocks the prompt or
+// adds context alongside it:
+//
+//	[[hooks.user_prompt_submit]]
+//	name = "no-secrets"
+//	match = '(AKIA[0-9A-Z]{16}|-----BEGIN [A-Z ]*PRIVATE KEY-----)'
+//	block = true
+//	reason = "That prompt looks like it contains a credential."
+//
+//	[[hooks.user_prompt_submit]]
+//	name = "job-context"
+//	additional_context = "Current job: {{.JobTitle}} ({{.PlanName}})"
+//	include_file = "{{.JobFilePath}}"
+//
+// match is a regexp over the prompt; an entry without one applies to every
+// prompt, except that block requires a match. additional_context and
+// include_file are templates as for post_tool_use reminders (see
+// reminder_template.go). The first blocking entry wins and suppresses all
+// context. An entry with an invalid regexp never fires.
+//
+// Each prompt is appended to prompts.jsonl in the per-session hooks state
+// dir. Blocked prompts are recorded without their text, since the usual
+// reason to block is that the text must not be kept.
+
+// promptsLogFile is the per-session record of submitted prompts.
+const promptsLogFile = "prompts.jsonl"
+
+// UserPromptRule is one [[hooks.user_prompt_submit]] entry.
+type UserPromptRule struct {
+	Name              string `yaml:"name"`
+	Match             string `yaml:"match"`
+	Block             bool   `yaml:"block"`
+	Reason            string `yaml:"reason"`
+	AdditionalContext string `yaml:"additional_context"`
+	IncludeFile       string `yaml:"include_file"`
+}
+
+// userPromptDecision is the outcome of evaluating the rules for one prompt.
+// BlockedBy names the blocking rule; empty means the prompt goes through
+// with Context (possibly empty) attached.
+type userPromptDecision struct {
+	BlockedBy string
+	Reason    string
+	Context   []string
+}
+
+// promptRecord is one line of prompts.jsonl.
+type promptRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Prompt    string    `json:"prompt,omitempty"`
+	Length    int       `json:"length"`
+	BlockedBy string    `json:"blocked_by,omitempty"`
+}
+
+// matchesPrompt reports whether the rule's match pattern applies to the
+// prompt. A rule without a pattern matches every prompt.
+func (r UserPromptRule) matchesPrompt(prompt string) bool {
+	if r.Match == "" {
+		return true
+	}
+	re, err := regexp.Compile(r.Match)
+	if err != nil {
+		logging.NewLogger("hooks.user-prompt-submit").WithFields(logrus.Fields{
+			"rule":  r.Name,
+			"error": err.Error(),
+		}).Warn("Invalid user_prompt_submit pattern")
+		return false
+	}
+	return re.MatchString(prompt)
+}
+
+// evaluateUserPromptRules applies the rules in order. vars is only built
+// when a context entry matches.
+func evaluateUserPromptRules(rules []UserPromptRule, prompt string, vars func() reminderVars) userPromptDecision {
+	var decision userPromptDecision
+	var v *reminderVars
+	for _, rule := range rules {
+		if rule.Block && rule.Match == "" {
+			continue
+		}
+		if !rule.matchesPrompt(prompt) {
+			continue
+		}
+		if rule.Block {
+			reason := rule.Reason
+			if reason == "" {
+				reason = "Prompt blocked by grove.toml user_prompt_submit rule " + ruleLabel(rule.Name, rule.Match)
+			}
+			return userPromptDecision{BlockedBy: ruleLabel(rule.Name, rule.Match), Reason: reason}
+		}
+		if rule.AdditionalContext == "" && rule.IncludeFile == "" {
+			continue
+		}
+		if v == nil {
+			built := vars()
+			v = &built
+		}
+		if text := renderContext(rule.Name, rule.AdditionalContext, rule.IncludeFile, *v); text != "" {
+			decision.Context = append(decision.Context, text)
+		}
+	}
+	return decision
+}
+
+// loadUserPromptRules reads [[hooks.user_prompt_submit]] from the grove.toml
+// governing workingDir.
+func loadUserPromptRules(workingDir string) []UserPromptRule {
+	if workingDir == "" {
+		return nil
+	}
+	cfg, err := config.LoadFrom(workingDir)
+	if err != nil {
+		return nil
+	}
+	var hooksConfig struct {
+		UserPromptSubmit []UserPromptRule `yaml:"user_prompt_submit"`
+	}
+	if err := cfg.UnmarshalExtension("hooks", &hooksConfig); err != nil {
+		return nil
+	}
+	return hooksConfig.UserPromptSubmit
+}
+
+// recordPrompt appends the prompt to the session's prompts.jsonl.
+func recordPrompt(sessionID, prompt string, decision userPromptDecision, now time.Time) error {
+	if sessionID == "" {
+		return nil
+	}
+	dir := filepath.Join(paths.StateDir(), "hooks", "sessions", sessionID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	record := promptRecord{Timestamp: now, Length: len(prompt), BlockedBy: decision.BlockedBy}
+	if decision.BlockedBy == "" {
+		record.Prompt = prompt
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, promptsLogFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// submitUserPrompt registers the session for a prompt that goes through,
+// then records the prompt. A blocked prompt never reaches Claude, so the
+// session stays as it was. Registration comes first because
+// EnsureSessionExists removes a session dir it finds stale, and would take a
+// prompts.jsonl written just before it along.
+func submitUserPrompt(slog *logrus.Entry, data UserPromptSubmitInput, decision userPromptDecision, ensureSession func(sessionID, transcriptPath string) error) {
+	if decision.BlockedBy == "" {
+		if err := ensureSession(data.SessionID, data.TranscriptPath); err != nil {
+			slog.WithError(err).Warn("Failed to ensure session exists")
+		}
+	}
+	if err := recordPrompt(data.SessionID, data.Prompt, decision, time.Now()); err != nil {
+		slog.WithFields(logrus.Fields{"session_id": data.SessionID, "error": err.Error()}).Warn("Failed to record prompt")
+	}
+}
+
+// newUserPromptResponse builds the hook output for a decision, or nil when
+// there is nothing to say.
+func newUserPromptResponse(decision userPromptDecision) *UserPromptSubmitResponse {
+	if decision.BlockedBy != "" {
+		return &UserPromptSubmitResponse{Decision: "block", Reason: decision.Reason}
+	}
+	if len(decision.Context) == 0 {
+		return nil
+	}
+	return &UserPromptSubmitResponse{
+		HookSpecificOutput: &UserPromptSubmitHookOutput{
+			HookEventName:     "UserPromptSubmit",
+			AdditionalContext: strings.Join(decision.Context, "\n\n---\n\n"),
+		},
+	}
+}
+
+// RunUserPromptSubmitHook handles the UserPromptSubmit hook: it applies the
+// prompt policy, records the prompt, and marks the session running as soon
+// as the user submits instead of at the first tool call.
+func RunUserPromptSubmitHook() {
+	slog := logging.NewLogger("hooks.user-prompt-submit")
+
+	ctx, err := NewHookContext()
+	if err != nil {
+		slog.WithError(err).Error("Error initializing hook context")
+		os.Exit(1)
+	}
+
+	var data UserPromptSubmitInput
+	if err := json.Unmarshal(ctx.RawInput, &data); err != nil {
+		slog.WithError(err).Error("Error parsing JSON")
+		os.Exit(1)
+	}
+
+	workingDir := data.Cwd
+	if workingDir == "" {
+		workingDir = os.Getenv("PWD")
+	}
+	decision := evaluateUserPromptRules(loadUserPromptRules(workingDir), data.Prompt, func() reminderVars {
+		return newReminderVars(data.SessionID, "", nil, workingDir)
+	})
+
+	submitUserPrompt(slog, data, decision, ctx.EnsureSessionExists)
+
+	eventData := map[string]any{
+		"prompt_length": len(data.Prompt),
+		"context_count": len(decision.Context),
+	}
+	if decision.BlockedBy != "" {
+		eventData["blocked_by"] = decision.BlockedBy
+	}
+	if err := ctx.LogEvent(models.EventType("user_prompt_submit"), eventData); err != nil {
+		slog.WithError(err).Warn("Failed to log event")
+	}
+
+	if response := newUserPromptResponse(decision); response != nil {
+		payload, err := json.Marshal(response)
+		if err != nil {
+			return
+		}
+		fmt.Print(string(payload))
+	}
+}
@@ -401,14 +401,15 @@ func workflowEventForwardable(ev models.WorkflowEvent) bool {
 // forwardWorkflowEvent publishes a workflow event to the daemon,
 // best-effort: it never fails the hook, never writes to stdout (hook
 // response contracts must stay pristine — errors go to stderr via log), and
-// waits at most workflowForwardTimeout. Forwarding is skipped when the
-// repo-scoped marker file disables the "workflow-forwarding" hook or when
-// the event lacks the keying workflowEventForwardable requires.
+// waits at most workflowForwardTimeout. Forwarding is skipped when a marker
+// for the repo, worktree or the event's session disables the
+// "workflow-forwarding" hook or when the event lacks the keying
+// workflowEventForwardable requires.
 func forwardWorkflowEvent(client daemon.Client, workingDir string, ev models.WorkflowEvent) {
 	if client == nil || !workflowEventForwardable(ev) {
 		return
 	}
-	if IsHookDisabledByMarker(workingDir, WorkflowForwardingHookName) {
+	if _, disabled := FindHookMarker(workingDir, ev.ClaudeSessionID, WorkflowForwardingHookName); disabled {
 		return
 	}
 
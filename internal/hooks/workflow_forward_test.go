@@ -375,6 +375,23 @@ func TestWorkflowEventForwardable(t *testing.T) {
 	}
 }
 
+func TestForwardWorkflowEventHonorsSessionMarker(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GROVE_HOME", "")
+	work := t.TempDir()
+
+	registerTestSession(t, "sess-off")
+	if err := DisableHookInScope(work, "sess-off", WorkflowForwardingHookName, "", DisableSession, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := &recordingDaemon{}
+	forwardWorkflowEvent(recorder, work, models.WorkflowEvent{Kind: models.WorkflowAgentStarted, AgentID: "a1", ClaudeSessionID: "sess-off"})
+	forwardWorkflowEvent(recorder, work, models.WorkflowEvent{Kind: models.WorkflowAgentStarted, AgentID: "a2", ClaudeSessionID: "sess-on"})
+	if len(recorder.events) != 1 || recorder.events[0].AgentID != "a2" {
+		t.Fatalf("forwarded %+v, want only the event of the session without a marker", recorder.events)
+	}
+}
+
 func TestWorkflowEventFromSubagentStart(t *testing.T) {
 	now := time.Date(2026, 6, 10, 17, 7, 10, 0, time.UTC)
 
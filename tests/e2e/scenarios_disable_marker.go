@@ -1,10 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -14,11 +18,36 @@ import (
 	"github.com/grovetools/tend/pkg/harness"
 )
 
-// markerPath mirrors hooks/internal/hooks.HookMarkerPath, kept inline so the
-// e2e binary doesn't depend on internal packages.
+// markerPath mirrors hooks/internal/hooks.HookMarkerPath (the repo scope),
+// kept inline so the e2e binary doesn't depend on internal packages.
 func markerPath(workingDir, hookName string) string {
-	repo := strings.ToLower(filepath.Base(workingDir))
-	return filepath.Join(paths.StateDir(), "hooks", "disabled", repo, strings.ToLower(hookName))
+	commonDir, name := workingDir, filepath.Base(workingDir)
+	out, err := exec.Command("git", "-C", workingDir, "rev-parse", "--path-format=absolute", "--git-common-dir", "--show-toplevel").Output()
+	if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); err == nil && len(lines) == 2 {
+		commonDir = lines[0]
+		name = strings.TrimSuffix(filepath.Base(commonDir), ".git")
+		if name == "" {
+			name = filepath.Base(filepath.Dir(commonDir))
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(commonDir); err == nil {
+		commonDir = resolved
+	}
+	sum := sha256.Sum256([]byte(commonDir))
+	repo := markerSlug(name) + "-" + hex.EncodeToString(sum[:6])
+	return filepath.Join(paths.StateDir(), "hooks", "markers", repo, markerSlug(hookName))
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// markerSlug mirrors hooks/internal/hooks.slugifyHookName, which keys marker
+// paths on both the repo and the hook name.
+func markerSlug(name string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-"), "-")
+	if slug == "" {
+		return "hook"
+	}
+	return slug
 }
 
 // HookDisableMarkerSkipsHookScenario verifies that a marker file created via